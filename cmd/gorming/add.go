@@ -0,0 +1,464 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	goparser "go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jinzhu/inflection"
+	"github.com/oSethoum/gorming"
+	"github.com/oSethoum/gorming/parser"
+	"github.com/oSethoum/gorming/types"
+	"github.com/oSethoum/gorming/utils"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/imports"
+)
+
+type fieldSpec struct {
+	name     string
+	typ      string
+	gorm     string
+	validate string
+}
+
+// insertion is some text to put at an offset of a file.
+type insertion struct {
+	offset int
+	text   string
+}
+
+// modelsPackage is the parsed folder of the models.
+type modelsPackage struct {
+	dir   string
+	name  string
+	fset  *token.FileSet
+	files map[string]*ast.File
+	src   map[string][]byte
+}
+
+// runAdd runs gorming add model Name [flags].
+func runAdd(args []string) int {
+	if len(args) < 2 || args[0] != "model" {
+		fmt.Fprintln(os.Stderr, `usage: gorming add model Name [-fields "Name:type[:rules]..."] [-belongs-to Model,...] [-dir db] [-file models.go]`)
+		return 2
+	}
+
+	name := args[1]
+	flags := flag.NewFlagSet("add model", flag.ContinueOnError)
+	fields := flags.String("fields", "", `space separated fields as Name:type or Name:type:rules, rules are validate rules like "notEmpty;maxLen=20"`)
+	belongsTo := flags.String("belongs-to", "", "comma separated models the new one belongs to, each gets a has-many field back")
+	dir := flags.String("dir", "db", "folder of the models package")
+	file := flags.String("file", "", "file of the models folder to add the model to, defaults to the one declaring Model")
+	generate := flags.String("generate", "generate/main.go", "generate program to register the model in")
+	if err := flags.Parse(args[2:]); err != nil {
+		return 2
+	}
+
+	if err := addModel(name, *fields, *belongsTo, *dir, *file, *generate); err != nil {
+		fmt.Fprintf(os.Stderr, "gorming: %s \n", err.Error())
+		return 1
+	}
+	return 0
+}
+
+func addModel(name string, fieldsSpec string, belongsTo string, dir string, file string, generate string) error {
+	if !token.IsIdentifier(name) || !token.IsExported(name) {
+		return fmt.Errorf("model name %q is not an exported identifier", name)
+	}
+
+	root, module, err := utils.CurrentGoMod()
+	if err != nil {
+		return err
+	}
+	naming := types.Snake
+	if configFile := gorming.FindConfig(root); configFile != "" {
+		if config, err := gorming.LoadConfig(configFile); err == nil && config.Case != 0 {
+			naming = config.Case
+		}
+	}
+
+	pkg, err := loadModels(dir)
+	if err != nil {
+		return err
+	}
+	if spec, ok := pkg.find(name); ok && spec.st == nil {
+		return fmt.Errorf("type %s already exists in %s and is not a struct", name, dir)
+	} else if ok {
+		return fmt.Errorf("model %s already exists in %s", name, dir)
+	}
+
+	fields, err := parseFields(fieldsSpec)
+	if err != nil {
+		return err
+	}
+
+	edits := map[string][]insertion{}
+	created := map[string]bool{}
+
+	parents := []string{}
+	for _, parent := range strings.Split(belongsTo, ",") {
+		if parent = strings.TrimSpace(parent); parent != "" {
+			parents = append(parents, parent)
+		}
+	}
+
+	for _, parent := range parents {
+		spec, ok := pkg.find(parent)
+		if !ok {
+			return fmt.Errorf("cannot find model %s in %s", parent, dir)
+		}
+		if spec.st == nil {
+			return fmt.Errorf("type %s in %s is not a struct", parent, dir)
+		}
+
+		key := parent + "ID"
+		if !hasField(fields, key) {
+			fields = append(fields, fieldSpec{name: key, typ: "uint", gorm: "index"})
+		}
+		fields = append(fields, fieldSpec{name: parent, typ: "*" + parent})
+
+		// the has-many field back on the parent
+		many := fieldSpec{name: inflection.Plural(name), typ: "[]" + name}
+		if structHasField(spec.st, many.name) {
+			continue
+		}
+		line, err := fieldLine(many, naming)
+		if err != nil {
+			return err
+		}
+		edits[spec.file] = append(edits[spec.file], insertion{
+			offset: pkg.fset.Position(spec.st.Fields.Closing).Offset,
+			text:   line,
+		})
+	}
+
+	model := new(bytes.Buffer)
+	fmt.Fprintf(model, "\ntype %s struct {\n", name)
+	if _, ok := pkg.find("Model"); ok && name != "Model" {
+		model.WriteString("\tModel\n")
+	}
+	for _, f := range fields {
+		line, err := fieldLine(f, naming)
+		if err != nil {
+			return err
+		}
+		model.WriteString(line)
+	}
+	model.WriteString("}\n")
+
+	target := filepath.Join(dir, file)
+	if file == "" {
+		target = filepath.Join(dir, "models.go")
+		if spec, ok := pkg.find("Model"); ok {
+			target = spec.file
+		}
+	}
+	if _, ok := pkg.src[target]; ok {
+		edits[target] = append(edits[target], insertion{offset: len(pkg.src[target]), text: model.String()})
+	} else {
+		created[target] = true
+		pkg.src[target] = []byte("package " + pkg.name + "\n")
+		edits[target] = append(edits[target], insertion{offset: len(pkg.src[target]), text: model.String()})
+	}
+
+	outputs := map[string][]byte{}
+	for filename, list := range edits {
+		content, err := formatSource(filename, splice(pkg.src[filename], list))
+		if err != nil {
+			return err
+		}
+		outputs[filename] = content
+	}
+
+	registered := false
+	if content, err := os.ReadFile(generate); err == nil {
+		importPath := module
+		if rel, err := filepath.Rel(root, pkg.dir); err == nil && rel != "." {
+			importPath = path.Join(module, filepath.ToSlash(rel))
+		}
+		content, registered, err = registerModel(generate, content, importPath, pkg.name, name)
+		if err != nil {
+			return err
+		}
+		if registered {
+			outputs[generate] = content
+		}
+	}
+
+	filenames := []string{}
+	for filename := range outputs {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+	for _, filename := range filenames {
+		if err := os.WriteFile(filename, outputs[filename], 0666); err != nil {
+			return err
+		}
+		action := "update"
+		if created[filename] {
+			action = "create"
+		}
+		fmt.Printf("%-7s %s\n", action, filename)
+	}
+	if !registered {
+		fmt.Printf("gorming: add %s.%s{} to the tables of the engine or to the models of the config file\n", pkg.name, name)
+	}
+	return nil
+}
+
+func parseFields(spec string) ([]fieldSpec, error) {
+	fields := []fieldSpec{}
+	for _, part := range strings.Fields(spec) {
+		pieces := strings.SplitN(part, ":", 3)
+		if len(pieces) < 2 {
+			return nil, fmt.Errorf("field %q has to be Name:type", part)
+		}
+		f := fieldSpec{name: pieces[0], typ: pieces[1]}
+		if len(pieces) == 3 {
+			f.validate = pieces[2]
+		}
+		if !token.IsIdentifier(f.name) || !token.IsExported(f.name) {
+			return nil, fmt.Errorf("field name %q is not an exported identifier", f.name)
+		}
+		if _, err := goparser.ParseExpr(f.typ); err != nil {
+			return nil, fmt.Errorf("field %s: invalid type %q", f.name, f.typ)
+		}
+		if hasField(fields, f.name) {
+			return nil, fmt.Errorf("field %s is repeated", f.name)
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+func hasField(fields []fieldSpec, name string) bool {
+	for _, f := range fields {
+		if f.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonName(name string, naming types.Case) string {
+	switch naming {
+	case types.Camel:
+		return utils.Camel(name)
+	case types.Pascal:
+		return name
+	}
+	return utils.Snake(name)
+}
+
+// fieldLine renders a struct field, the tags are checked with the parser
+// so the schema reads them back as written.
+func fieldLine(f fieldSpec, naming types.Case) (string, error) {
+	tag := jsonName(f.name, naming)
+	if strings.HasPrefix(f.typ, "*") || strings.HasPrefix(f.typ, "[]") {
+		tag += ",omitempty"
+	}
+	tag = `json:` + strconv.Quote(tag)
+	if f.gorm != "" {
+		tag += ` gorm:` + strconv.Quote(f.gorm)
+	}
+	if f.validate != "" {
+		tag += ` validate:` + strconv.Quote(f.validate)
+	}
+
+	if _, err := parser.Tags(reflect.StructTag(tag)); err != nil {
+		return "", fmt.Errorf("field %s: %w", f.name, err)
+	}
+	return fmt.Sprintf("\t%s %s `%s`\n", f.name, f.typ, tag), nil
+}
+
+type structDecl struct {
+	file string
+	st   *ast.StructType
+}
+
+func loadModels(dir string) (*modelsPackage, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := &modelsPackage{
+		fset:  token.NewFileSet(),
+		files: map[string]*ast.File{},
+		src:   map[string][]byte{},
+	}
+	if pkg.dir, err = filepath.Abs(dir); err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		name := filepath.Join(dir, entry.Name())
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		src, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		f, err := goparser.ParseFile(pkg.fset, name, src, goparser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		pkg.files[name], pkg.src[name] = f, src
+		pkg.name = f.Name.Name
+	}
+
+	if pkg.name == "" {
+		return nil, errors.New("no go files in " + dir)
+	}
+	return pkg, nil
+}
+
+// find returns the declaration of the type name, in the first file by name
+// when several declare it. st is nil when the type is not a struct.
+func (pkg *modelsPackage) find(name string) (structDecl, bool) {
+	files := []string{}
+	for file := range pkg.files {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		for _, decl := range pkg.files[file].Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, s := range gen.Specs {
+				spec := s.(*ast.TypeSpec)
+				if spec.Name.Name != name {
+					continue
+				}
+				st, _ := spec.Type.(*ast.StructType)
+				return structDecl{file: file, st: st}, true
+			}
+		}
+	}
+	return structDecl{}, false
+}
+
+func structHasField(st *ast.StructType, name string) bool {
+	for _, field := range st.Fields.List {
+		for _, n := range field.Names {
+			if n.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func splice(src []byte, list []insertion) []byte {
+	sort.SliceStable(list, func(i, j int) bool { return list[i].offset > list[j].offset })
+	out := append([]byte{}, src...)
+	for _, in := range list {
+		out = append(out[:in.offset], append([]byte(in.text), out[in.offset:]...)...)
+	}
+	return out
+}
+
+func formatSource(filename string, src []byte) ([]byte, error) {
+	out, err := imports.Process(filename, src, &imports.Options{Comments: true, TabIndent: true, TabWidth: 8})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return out, nil
+}
+
+// registerModel adds pkgName.name{} to the tables of the engine call of the
+// generate program, the call is the first one taking a []any literal.
+func registerModel(filename string, src []byte, importPath string, pkgName string, name string) ([]byte, bool, error) {
+	fset := token.NewFileSet()
+	f, err := goparser.ParseFile(fset, filename, src, goparser.ParseComments)
+	if err != nil {
+		return nil, false, err
+	}
+
+	alias := pkgName
+	for _, spec := range f.Imports {
+		if p, _ := strconv.Unquote(spec.Path.Value); p == importPath && spec.Name != nil {
+			alias = spec.Name.Name
+		}
+	}
+
+	var tables *ast.CompositeLit
+	ast.Inspect(f, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || tables != nil || len(call.Args) == 0 {
+			return tables == nil
+		}
+		if lit, ok := call.Args[0].(*ast.CompositeLit); ok && isAnySlice(lit.Type) {
+			tables = lit
+		}
+		return tables == nil
+	})
+	if tables == nil {
+		return nil, false, nil
+	}
+
+	for _, elt := range tables.Elts {
+		if lit, ok := elt.(*ast.CompositeLit); ok {
+			if sel, ok := lit.Type.(*ast.SelectorExpr); ok && sel.Sel.Name == name {
+				return nil, false, nil
+			}
+		}
+	}
+
+	value := alias + "." + name + "{}"
+	rbrace := fset.Position(tables.Rbrace).Offset
+	text := value
+	switch {
+	case fset.Position(tables.Lbrace).Line != fset.Position(tables.Rbrace).Line:
+		text = value + ",\n"
+	case len(tables.Elts) > 0 && !strings.HasSuffix(strings.TrimSpace(string(src[:rbrace])), ","):
+		text = ", " + value
+	}
+	src = splice(src, []insertion{{offset: rbrace, text: text}})
+
+	if f, err = goparser.ParseFile(fset, filename, src, goparser.ParseComments); err != nil {
+		return nil, false, err
+	}
+	if alias == path.Base(importPath) {
+		astutil.AddImport(fset, f, importPath)
+	} else {
+		astutil.AddNamedImport(fset, f, alias, importPath)
+	}
+
+	buffer := new(bytes.Buffer)
+	if err := format.Node(buffer, fset, f); err != nil {
+		return nil, false, err
+	}
+	content, err := formatSource(filename, buffer.Bytes())
+	return content, err == nil, err
+}
+
+func isAnySlice(expr ast.Expr) bool {
+	array, ok := expr.(*ast.ArrayType)
+	if !ok || array.Len != nil {
+		return false
+	}
+	switch elt := array.Elt.(type) {
+	case *ast.Ident:
+		return elt.Name == "any"
+	case *ast.InterfaceType:
+		return len(elt.Methods.List) == 0
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of testdata")
+
+// copyProject copies the project to a temporary module and makes it the
+// working directory.
+func copyProject(t *testing.T, project string) string {
+	t.Helper()
+	dir := t.TempDir()
+	err := filepath.WalkDir(project, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(project, name)
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(rel)), 0o755); err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dir, rel), content, 0o644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n\ngo 1.22\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir
+}
+
+// compareGolden compares the files of dir with the ones of golden, a file
+// that is not in golden has to be the same as in the project.
+func compareGolden(t *testing.T, dir string, project string, golden string) {
+	t.Helper()
+	err := filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || d.Name() == "go.mod" {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, name)
+		got, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		original, err := os.ReadFile(filepath.Join(project, rel))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		want, err := os.ReadFile(filepath.Join(golden, rel))
+		if os.IsNotExist(err) {
+			want, err = original, nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if *update && golden != project && !bytes.Equal(got, original) {
+			if err := os.MkdirAll(filepath.Join(golden, filepath.Dir(rel)), 0o755); err != nil {
+				return err
+			}
+			return os.WriteFile(filepath.Join(golden, rel), got, 0o644)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s:\ngot\n%s\nwant\n%s", rel, got, want)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAddModel(t *testing.T) {
+	testdata, err := filepath.Abs("testdata/add")
+	if err != nil {
+		t.Fatal(err)
+	}
+	project := filepath.Join(testdata, "project")
+
+	tests := []struct {
+		name      string
+		model     string
+		fields    string
+		belongsTo string
+		err       string
+	}{
+		{name: "fields", model: "Invoice", fields: "Number:string:notEmpty;maxLen=20 Total:float64:min=0 DueAt:*time.Time"},
+		{name: "belongs_to", model: "Invoice", fields: "Number:string", belongsTo: "User"},
+		{name: "exists", model: "User", err: "model User already exists in db"},
+		{name: "not_struct", model: "Status", err: "type Status already exists in db and is not a struct"},
+		{name: "belongs_to_not_struct", model: "Invoice", belongsTo: "Status", err: "type Status in db is not a struct"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := copyProject(t, project)
+
+			err := addModel(test.model, test.fields, test.belongsTo, "db", "", "generate/main.go")
+			if test.err == "" && err != nil {
+				t.Fatal(err)
+			}
+			if test.err != "" && (err == nil || err.Error() != test.err) {
+				t.Fatalf("got error %v, want %s", err, test.err)
+			}
+
+			golden := filepath.Join(testdata, test.name)
+			if test.err != "" {
+				// nothing is written
+				golden = project
+			}
+			compareGolden(t, dir, project, golden)
+		})
+	}
+}
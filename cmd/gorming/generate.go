@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/oSethoum/gorming"
+	"github.com/oSethoum/gorming/types"
+	"github.com/oSethoum/gorming/utils"
+)
+
+func generateDir(args []string) string {
+	if len(args) > 0 {
+		return args[0]
+	}
+	return "generate"
+}
+
+// runProject runs the generator of the project with the given mode, in
+// process from the models of the config file or of its groups when it has
+// some and with the generate program otherwise.
+func runProject(args []string, mode string) int {
+	root, _, err := utils.CurrentGoMod()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gorming: %s \n", err.Error())
+		return 1
+	}
+
+	configFile := gorming.FindConfig(root)
+	if configFile == "" || len(args) > 0 {
+		return runGenerate(generateDir(args), mode)
+	}
+
+	config, err := gorming.LoadConfig(configFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+
+	// groups need the generate program as soon as one of them has tables
+	// passed from go code
+	inProcess := len(config.Models) > 0 || len(config.Groups) > 0
+	for _, group := range config.Groups {
+		inProcess = inProcess && len(group.Models) > 0
+	}
+	if !inProcess {
+		return runGenerate(generateDir(args), mode)
+	}
+
+	// the engine loads the config file itself
+	err = gorming.NewGenerator(types.Config{Check: mode == "check", Report: mode == "report", Doctor: mode == "doctor"}).Generate(nil)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	return 0
+}
+
+// runGenerate runs the project generate program with the given mode and
+// returns its exit code.
+func runGenerate(dir string, mode string) int {
+	cmd := exec.Command("go", "run", "main.go")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GORMING_MODE="+mode)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	err := cmd.Run()
+	if err == nil {
+		return 0
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	fmt.Fprintf(os.Stderr, "gorming: %s \n", err.Error())
+	return 1
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/oSethoum/gorming/utils"
+)
+
+type initOptions struct {
+	Module     string
+	DBKind     string
+	Server     string
+	Typescript string
+	Examples   bool
+}
+
+var dbKinds = map[string]string{
+	"sqlite":   "SQLite",
+	"mysql":    "MySQL",
+	"postgres": "Postgres",
+}
+
+var servers = map[string]string{
+	"fiber": "Fiber",
+	"wails": "Wails",
+}
+
+// runInit scaffolds the models, the generate program and for fiber a
+// runnable server in the current folder, existing files are kept.
+func runInit(args []string) int {
+	flags := flag.NewFlagSet("init", flag.ContinueOnError)
+	module := flags.String("module", "", "module path, defaults to the one of go.mod or the folder name")
+	dbKind := flags.String("db", "sqlite", "database: sqlite, mysql or postgres")
+	server := flags.String("server", "fiber", "server: fiber or wails")
+	typescript := flags.String("ts", "", "typescript client folder, defaults to client/typescript/gorming")
+	examples := flags.Bool("examples", false, "add example User and Post models")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	options := initOptions{Typescript: filepath.ToSlash(*typescript), Examples: *examples}
+	var ok bool
+	if options.DBKind, ok = dbKinds[*dbKind]; !ok {
+		fmt.Fprintf(os.Stderr, "gorming: unknown db %q \n", *dbKind)
+		return 2
+	}
+	if options.Server, ok = servers[*server]; !ok {
+		fmt.Fprintf(os.Stderr, "gorming: unknown server %q \n", *server)
+		return 2
+	}
+
+	wd, _ := os.Getwd()
+	root, current, err := utils.CurrentGoMod()
+	switch {
+	case err == nil && root != wd:
+		fmt.Fprintf(os.Stderr, "gorming: run init at the module root %s \n", root)
+		return 1
+	case err == nil:
+		if *module != "" && *module != current {
+			fmt.Fprintf(os.Stderr, "gorming: %s already declares module %s \n", filepath.Join(root, "go.mod"), current)
+			return 1
+		}
+		options.Module = current
+	default:
+		options.Module = utils.Choice(*module, filepath.Base(wd))
+		cmd := exec.Command("go", "mod", "init", options.Module)
+		cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			fmt.Fprintf(os.Stderr, "gorming: %s \n", err.Error())
+			return 1
+		}
+	}
+
+	writeTemplate("generate", "generate/generate.go", options)
+	writeTemplate("main", "generate/main.go", options)
+	writeTemplate("models", "db/models.go", options)
+	if *server == "fiber" {
+		writeTemplate("server", "main.go", options)
+		writeTemplate("setup", "routes/setup.go", options)
+	}
+
+	fmt.Println("\nnext: go get github.com/oSethoum/gorming && go generate ./... && go mod tidy")
+	return 0
+}
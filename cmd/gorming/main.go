@@ -1,12 +1,52 @@
 package main
 
-import "embed"
+import (
+	"embed"
+	"fmt"
+	"os"
+)
 
 //go:embed templates
 var templates embed.FS
 
+const usage = `usage: gorming [command]
+
+commands:
+  init [flags]             scaffold the models, the generate program and
+                           a fiber server (default), see gorming init -h
+  generate [generate dir]  run the generator
+  check [generate dir]     fail when the generated files are stale
+  doctor [generate dir]    report the problems of the models, generates nothing
+  watch [generate dir]     run the generator on every change of the models
+  add model Name [flags]   add a model to the models package, see gorming add model Name -h
+
+generate, check, doctor and watch read the models of gorming.yaml or gorming.json from
+source when it lists some, and run the generate program otherwise.
+`
+
 func main() {
-	writeTemplate("generate", "./generate/generate.go")
-	writeTemplate("main", "./generate/main.go")
-	writeTemplate("models", "./db/models.go")
+	command := "init"
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
+
+	switch command {
+	case "init":
+		os.Exit(runInit(os.Args[min(len(os.Args), 2):]))
+	case "generate":
+		os.Exit(runProject(os.Args[2:], ""))
+	case "check":
+		os.Exit(runProject(os.Args[2:], "check"))
+	case "doctor":
+		os.Exit(runProject(os.Args[2:], "doctor"))
+	case "add":
+		os.Exit(runAdd(os.Args[2:]))
+	case "watch":
+		os.Exit(runWatch(os.Args[2:]))
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
 }
@@ -0,0 +1,28 @@
+package db
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Model struct {
+	ID        uint           `json:"id,omitempty" gorm:"primarykey"`
+	CreatedAt *time.Time     `json:"created_at,omitempty"`
+	UpdatedAt *time.Time     `json:"updated_at,omitempty"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+}
+
+// User is an account of the app.
+type User struct {
+	Model
+	Name     string    `json:"name" validate:"minLen=3"` // shown in the header
+	Invoices []Invoice `json:"invoices,omitempty"`
+}
+
+type Invoice struct {
+	Model
+	Number string `json:"number"`
+	UserID uint   `json:"user_id" gorm:"index"`
+	User   *User  `json:"user,omitempty"`
+}
@@ -0,0 +1,27 @@
+package db
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Model struct {
+	ID        uint           `json:"id,omitempty" gorm:"primarykey"`
+	CreatedAt *time.Time     `json:"created_at,omitempty"`
+	UpdatedAt *time.Time     `json:"updated_at,omitempty"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+}
+
+// User is an account of the app.
+type User struct {
+	Model
+	Name string `json:"name" validate:"minLen=3"` // shown in the header
+}
+
+type Invoice struct {
+	Model
+	Number string     `json:"number" validate:"notEmpty;maxLen=20"`
+	Total  float64    `json:"total" validate:"min=0"`
+	DueAt  *time.Time `json:"due_at,omitempty"`
+}
@@ -0,0 +1,4 @@
+package db
+
+// Status is the state of an invoice.
+type Status string
@@ -0,0 +1,17 @@
+//go:build ignore
+
+package main
+
+import (
+	"example.com/app/db"
+
+	"github.com/oSethoum/gorming"
+	"github.com/oSethoum/gorming/types"
+)
+
+func main() {
+	engine := gorming.New(types.Config{Package: "example.com/app"})
+	engine([]any{
+		db.User{},
+	})
+}
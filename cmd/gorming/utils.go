@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"fmt"
 	"log"
 	"os"
 	"path"
@@ -9,7 +10,7 @@ import (
 	"text/template"
 )
 
-func parseTemplate(templateName string) *bytes.Buffer {
+func parseTemplate(templateName string, data any) *bytes.Buffer {
 
 	file, err := templates.ReadFile("templates/" + templateName + ".tmpl")
 	if err != nil {
@@ -20,20 +21,25 @@ func parseTemplate(templateName string) *bytes.Buffer {
 	if err != nil {
 		log.Fatalf("gorming: error executing template %s, %s \n", templateName, err.Error())
 	}
-	err = engine.Execute(buffer, nil)
+	err = engine.Execute(buffer, data)
 	if err != nil {
 		log.Fatalf("gorming: error executing template %s, %s \n", templateName, err.Error())
 	}
 	return buffer
 }
 
-func writeTemplate(templateName string, filepath string) {
+func writeTemplate(templateName string, filepath string, data any) {
 	wd, _ := os.Getwd()
-	buffer := parseTemplate(templateName)
-	writeFile(path.Join(wd, filepath), buffer.Bytes())
+	buffer := parseTemplate(templateName, data)
+	if writeFile(path.Join(wd, filepath), buffer.Bytes()) {
+		fmt.Printf("create  %s\n", filepath)
+	} else {
+		fmt.Printf("exists  %s\n", filepath)
+	}
 }
 
-func writeFile(outPath string, data []byte) {
+// writeFile reports whether outPath was written, existing files are kept.
+func writeFile(outPath string, data []byte) bool {
 	err := os.MkdirAll(filepath.Dir(outPath), 0777)
 
 	if err != nil {
@@ -42,10 +48,11 @@ func writeFile(outPath string, data []byte) {
 	_, err = os.Stat(outPath)
 	if err == nil {
 		// File exist already
-		return
+		return false
 	}
-	err = os.WriteFile(outPath, data, 07777)
+	err = os.WriteFile(outPath, data, 0666)
 	if err != nil {
 		log.Fatalf("gorming: %s \n", err.Error())
 	}
+	return true
 }
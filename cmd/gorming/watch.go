@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/oSethoum/gorming"
+	"github.com/oSethoum/gorming/utils"
+)
+
+// runWatch runs the generator of the project again on every change of the
+// models, the config file or the generate program until it is interrupted,
+// failed runs are printed and watching goes on.
+func runWatch(args []string) int {
+	root, _, err := utils.CurrentGoMod()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gorming: %s \n", err.Error())
+		return 1
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gorming: %s \n", err.Error())
+		return 1
+	}
+	defer watcher.Close()
+
+	watched := map[string]bool{}
+	var snapshot map[string][32]byte
+
+	run := func() {
+		runProject(args, "report")
+
+		dirs, err := watchDirs(root, args)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "gorming: %s \n", err.Error())
+		}
+		added := 0
+		for _, dir := range dirs {
+			if watched[dir] {
+				continue
+			}
+			if err := watcher.Add(dir); err != nil {
+				fmt.Fprintf(os.Stderr, "gorming: %s \n", err.Error())
+				continue
+			}
+			watched[dir] = true
+			added++
+		}
+		// the outputs written by the run are part of the snapshot so they
+		// do not trigger the next one
+		snapshot = sources(root, watched)
+		if added > 0 {
+			fmt.Printf("gorming: watching %d folders for changes\n", len(watched))
+		}
+	}
+
+	run()
+
+	// editors write a file in several steps, changes are collected until
+	// the files settle
+	var settle <-chan time.Time
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return 0
+			}
+			if isSource(root, event.Name) {
+				settle = time.After(200 * time.Millisecond)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return 0
+			}
+			fmt.Fprintf(os.Stderr, "gorming: %s \n", err.Error())
+		case <-settle:
+			settle = nil
+			if current := sources(root, watched); !sameSources(snapshot, current) {
+				fmt.Println("gorming: change detected, generating")
+				run()
+			}
+		}
+	}
+}
+
+// watchDirs returns the folders of the module packages the models depend
+// on, they are the ones of the config file models and groups or of the
+// generate program imports. The module root is always watched for the config file.
+func watchDirs(root string, args []string) ([]string, error) {
+	dir, patterns := generateDir(args), []string{"main.go"}
+	if configFile := gorming.FindConfig(root); configFile != "" && len(args) == 0 {
+		config, err := gorming.LoadConfig(configFile)
+		if err != nil {
+			return []string{root}, err
+		}
+		models := config.Models
+		for _, group := range config.Groups {
+			models = append(models, group.Models...)
+		}
+		if len(models) > 0 {
+			dir, patterns = root, []string{}
+			for _, m := range models {
+				patterns = append(patterns, m.Package)
+			}
+		}
+	}
+
+	dirs := []string{root}
+	if dir != root {
+		if abs, err := filepath.Abs(dir); err == nil {
+			dirs = append(dirs, abs)
+		}
+	}
+
+	cmd := exec.Command("go", append([]string{"list", "-deps", "-f", "{{ .Dir }}"}, patterns...)...)
+	cmd.Dir = dir
+	stderr := new(bytes.Buffer)
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return dirs, fmt.Errorf("cannot list the model packages: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	for _, d := range strings.Fields(string(out)) {
+		if d != root && strings.HasPrefix(d, root+string(filepath.Separator)) {
+			dirs = append(dirs, d)
+		}
+	}
+	return dirs, nil
+}
+
+// isSource reports whether a change of name can change the generated
+// files, go files of the watched folders and the config file.
+func isSource(root string, name string) bool {
+	if strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go") {
+		return true
+	}
+	return filepath.Dir(name) == root && utils.In(filepath.Base(name), gorming.ConfigFiles...)
+}
+
+// sources hashes every source file of the watched folders.
+func sources(root string, dirs map[string]bool) map[string][32]byte {
+	hashes := map[string][32]byte{}
+	for dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := filepath.Join(dir, entry.Name())
+			if entry.IsDir() || !isSource(root, name) {
+				continue
+			}
+			if content, err := os.ReadFile(name); err == nil {
+				hashes[name] = sha256.Sum256(content)
+			}
+		}
+	}
+	return hashes
+}
+
+func sameSources(a map[string][32]byte, b map[string][32]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, hash := range a {
+		if b[name] != hash {
+			return false
+		}
+	}
+	return true
+}
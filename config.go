@@ -1,21 +1,146 @@
 package gorming
 
 import (
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+
 	"github.com/oSethoum/gorming/types"
 	"github.com/oSethoum/gorming/utils"
+	"gopkg.in/yaml.v3"
 )
 
-func defaultConfig(config types.Config) types.Config {
-	basePath, pkg := utils.CurrentGoMod()
-	config.Package = utils.Choice(config.Package, pkg)
+// ConfigFiles are looked up at the module root, the first one found is used.
+var ConfigFiles = []string{"gorming.yaml", "gorming.yml", "gorming.json"}
+
+// FindConfig returns the path of the config file in dir, empty when there
+// is none.
+func FindConfig(dir string) string {
+	for _, name := range ConfigFiles {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return filepath.Join(dir, name)
+		}
+	}
+	return ""
+}
+
+// LoadConfig reads a yaml or json config file, the keys are the json tags
+// of types.Config.
+func LoadConfig(path string) (types.Config, error) {
+	config := types.Config{}
+	file, err := os.ReadFile(path)
+	if err != nil {
+		return config, &types.Error{Path: path, Err: err}
+	}
+
+	if !strings.HasSuffix(path, ".json") {
+		var value any
+		if err := yaml.Unmarshal(file, &value); err != nil {
+			return config, &types.Error{Path: path, Err: err}
+		}
+		if file, err = json.Marshal(value); err != nil {
+			return config, &types.Error{Path: path, Err: err}
+		}
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(string(file)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return config, &types.Error{Path: path, Err: err}
+	}
+	return config, nil
+}
+
+// mergeConfig fills the zero fields of config with the ones of fileConfig,
+// the values set in go code win.
+func mergeConfig(config types.Config, fileConfig types.Config) types.Config {
+	mergeValue(reflect.ValueOf(&config).Elem(), reflect.ValueOf(fileConfig))
+	return config
+}
+
+func mergeValue(dst reflect.Value, src reflect.Value) {
+	for i := 0; i < dst.NumField(); i++ {
+		field := dst.Field(i)
+		if field.Kind() == reflect.Struct {
+			mergeValue(field, src.Field(i))
+			continue
+		}
+		if field.CanSet() && field.IsZero() {
+			field.Set(src.Field(i))
+		}
+	}
+}
+
+// resolveModels turns the relative packages of models into import paths of
+// the module pkg.
+func resolveModels(pkg string, models []types.Models) []types.Models {
+	resolved := []types.Models{}
+	for _, m := range models {
+		if strings.HasPrefix(m.Package, ".") {
+			m.Package = path.Join(pkg, m.Package)
+		}
+		resolved = append(resolved, m)
+	}
+	return resolved
+}
+
+func defaultConfig(config types.Config) (types.Config, error) {
+	root, pkg, err := utils.CurrentGoMod()
+	if err != nil && (config.Package == "" || config.Paths.BasePath == "") {
+		return config, &types.Error{Err: err}
+	}
+
+	if err == nil {
+		if path := FindConfig(root); path != "" {
+			fileConfig, err := LoadConfig(path)
+			if err != nil {
+				return config, err
+			}
+			if fileConfig.Paths.BasePath != "" && !filepath.IsAbs(fileConfig.Paths.BasePath) {
+				fileConfig.Paths.BasePath = filepath.Join(root, fileConfig.Paths.BasePath)
+			}
+			config = mergeConfig(config, fileConfig)
+		}
+		config.Package = utils.Choice(config.Package, pkg)
+		config.Paths.BasePath = utils.Choice(config.Paths.BasePath, root)
+		config.Models = resolveModels(pkg, config.Models)
+	}
+
+	if basePath, err := filepath.Abs(config.Paths.BasePath); err == nil {
+		config.Paths.BasePath = basePath
+	}
+	config.Paths.Manifest = utils.Choice(config.Paths.Manifest, "gorming.manifest.json")
 	config.ApiPackage = utils.Choice(config.ApiPackage, "main")
 	config.BackendPackage = utils.Choice(config.BackendPackage, "backend")
 	config.Case = utils.Choice(config.Case, types.Snake)
 	config.DBKind = utils.Choice(config.DBKind, types.SQLite)
 	config.Paths.TypescriptClient = utils.ArrayChoice(config.Paths.TypescriptClient, []string{"client/typescript/gorming"})
-	config.Paths.BasePath = utils.Choice(config.Paths.BasePath, basePath)
+	if config.Templates == nil && config.Paths.Templates != "" {
+		templatesPath := config.Paths.Templates
+		if !filepath.IsAbs(templatesPath) {
+			templatesPath = filepath.Join(config.Paths.BasePath, templatesPath)
+		}
+		config.Templates = os.DirFS(templatesPath)
+	}
 	if !utils.In(config.Server, types.Fiber, types.Wails) {
 		config.Server = types.Fiber
 	}
-	return config
+
+	// the cli selects the mode of the generate program through the environment
+	switch os.Getenv("GORMING_MODE") {
+	case "check":
+		config.Check = true
+	case "dry-run":
+		config.DryRun = true
+	case "diff":
+		config.Diff = true
+	case "report":
+		config.Report = true
+	case "doctor":
+		config.Doctor = true
+	}
+	return config, nil
 }
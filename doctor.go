@@ -0,0 +1,298 @@
+package gorming
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/oSethoum/gorming/parser"
+	"github.com/oSethoum/gorming/types"
+	"github.com/oSethoum/gorming/utils"
+)
+
+var gormKeys = []string{
+	"column", "type", "serializer", "size", "primaryKey", "primary_key", "unique", "default", "precision", "scale",
+	"not null", "notNull", "autoIncrement", "autoIncrementIncrement", "embedded", "embeddedPrefix",
+	"autoCreateTime", "autoUpdateTime", "index", "uniqueIndex", "check", "<-", "->", "-", "comment",
+	"foreignKey", "references", "polymorphic", "polymorphicType", "polymorphicId", "polymorphicValue", "many2many", "joinForeignKey",
+	"joinReferences", "constraint", "OnDelete", "OnUpdate",
+}
+
+var constraintActions = []string{"CASCADE", "SET NULL", "SET DEFAULT", "RESTRICT", "NO ACTION"}
+
+var typescriptKeys = []string{"type", "enum", "skipEdge", "optional"}
+
+var validatorRules = []string{
+	"notEmpty", "minLen", "maxLen", "url", "alphaSpace", "numeric", "alpha", "alphanumeric",
+	"cron", "email", "match", "in", "out", "min", "max",
+}
+
+// Doctor parses the models and reports their problems, nothing is
+// rendered or written. The error is only set when the models cannot be
+// parsed at all.
+func (g *Generator) Doctor(tables []any, Types ...any) ([]types.Finding, error) {
+	config, err := defaultConfig(g.config)
+	if err != nil {
+		return nil, err
+	}
+	return doctor(config, tables, Types...)
+}
+
+func doctor(config types.Config, tables []any, Types ...any) ([]types.Finding, error) {
+	schema, err := parse(config, tables, Types...)
+	if schema == nil {
+		return nil, err
+	}
+	return diagnose(config, schema, err), nil
+}
+
+func printFindings(w io.Writer, findings []types.Finding) {
+	if len(findings) == 0 {
+		fmt.Fprintln(w, "gorming: no problems found")
+		return
+	}
+	for _, finding := range findings {
+		fmt.Fprintln(w, finding)
+	}
+}
+
+// doctorError fails the run when a finding is an error.
+func doctorError(findings []types.Finding) error {
+	count := 0
+	for _, finding := range findings {
+		if finding.Severity == types.SeverityError {
+			count++
+		}
+	}
+	if count == 0 {
+		return nil
+	}
+	return &types.Error{Err: fmt.Errorf("doctor found %d errors", count)}
+}
+
+func diagnose(config types.Config, schema *types.Schema, parseErr error) []types.Finding {
+	findings := []types.Finding{}
+	add := func(severity types.Severity, table string, column string, format string, args ...any) {
+		findings = append(findings, types.Finding{Severity: severity, Table: table, Column: column, Message: fmt.Sprintf(format, args...)})
+	}
+
+	var errs types.Errors
+	if errors.As(parseErr, &errs) {
+		for _, e := range errs {
+			add(types.SeverityError, e.Table, e.Column, "%s", e.Err)
+		}
+	} else if parseErr != nil {
+		add(types.SeverityError, "", "", "%s", parseErr)
+	}
+
+	funcs := templateFunctions(&types.TemplateData{Schema: schema, Config: config})
+	tsName := funcs["tsName"].(func(types.Column) string)
+	tsType := funcs["tsType"].(func(types.Column) string)
+	tableName := funcs["tableName"].(func(types.Table) string)
+	columnName := funcs["columnName"].(func(types.Column) string)
+	embeddedColumns := funcs["embeddedColumns"].(func(types.Column) []types.Column)
+	typeValue := funcs["typeValue"].(func(types.Table, types.Column) string)
+
+	known := map[string]bool{}
+	for _, table := range append(append([]types.Table{}, schema.Tables...), schema.Types...) {
+		if known[table.Name] {
+			add(types.SeverityError, table.Name, "", "is both a table and a type, the typescript types collide")
+		}
+		known[table.Name] = true
+	}
+
+	tableNames := map[string]string{}
+	for _, table := range schema.Tables {
+		name := tableName(table)
+		if other, ok := tableNames[name]; ok {
+			add(types.SeverityError, table.Name, "", "table name %q collides with %s", name, other)
+			continue
+		}
+		tableNames[name] = table.Name
+	}
+
+	// the rows of two owners with the same type value cannot be told apart
+	typeValues := map[[3]string]string{}
+	for _, table := range schema.Tables {
+		for _, column := range table.Columns {
+			if column.Edge == nil || column.Edge.TypeKey == "" {
+				continue
+			}
+			key := [3]string{column.Edge.Table, column.Edge.TypeKey, typeValue(table, column)}
+			if other, ok := typeValues[key]; ok && other != table.Name {
+				add(types.SeverityError, table.Name, column.Name, "polymorphic value %q of %s.%s is also used by %s", key[2], key[0], key[1], other)
+				continue
+			}
+			typeValues[key] = table.Name
+		}
+	}
+
+	// a second edge to the same table that guessed the keys of the first
+	for _, table := range schema.Tables {
+		edges := map[types.Edge]string{}
+		for _, column := range table.Columns {
+			if column.Edge == nil {
+				continue
+			}
+			if other, ok := edges[*column.Edge]; ok {
+				add(types.SeverityWarning, table.Name, column.Name, "edge has the keys of %s, set gorm foreignKey", other)
+				continue
+			}
+			edges[*column.Edge] = column.Name
+		}
+	}
+
+	for _, table := range append(append([]types.Table{}, schema.Tables...), schema.Types...) {
+		jsonNames := map[string]string{}
+		columnNames := map[string]string{}
+		for _, column := range table.Columns {
+			tag := reflect.StructTag(column.Tags.Raw)
+			for _, finding := range gormFindings(tag.Get("gorm")) {
+				add(finding.Severity, table.Name, column.Name, "%s", finding.Message)
+			}
+			for _, finding := range typescriptFindings(tag.Get("typescript")) {
+				add(finding.Severity, table.Name, column.Name, "%s", finding.Message)
+			}
+			for _, finding := range validateFindings(tag.Get("validate")) {
+				add(finding.Severity, table.Name, column.Name, "%s", finding.Message)
+			}
+
+			if column.Edge == nil && !column.Tags.Gorm.Ignore {
+				stored, fields := []types.Column{column}, []string{column.Name}
+				if len(column.Fields) > 0 {
+					stored, fields = embeddedColumns(column), []string{}
+					for _, field := range stored {
+						fields = append(fields, column.Name+"."+field.Name)
+					}
+				}
+				for i, c := range stored {
+					field := fields[i]
+					name := columnName(c)
+					if other, ok := columnNames[name]; ok {
+						add(types.SeverityError, table.Name, column.Name, "column %q of %s is also used by %s", name, field, other)
+					} else {
+						columnNames[name] = field
+					}
+				}
+			}
+
+			if column.Tags.Json.Ignore {
+				continue
+			}
+
+			name := tsName(column)
+			if other, ok := jsonNames[name]; ok {
+				add(types.SeverityError, table.Name, column.Name, "json name %q is also used by %s", name, other)
+			} else {
+				jsonNames[name] = column.Name
+			}
+
+			if t := strings.TrimSuffix(tsType(column), "[]"); t == "any" || (t == column.RawType && t != "string" && !known[t]) {
+				add(types.SeverityWarning, table.Name, column.Name, "type %s maps to any in typescript, set typescript:\"type=...\"", column.Type)
+			}
+		}
+	}
+
+	return findings
+}
+
+func gormFindings(tag string) []types.Finding {
+	findings := []types.Finding{}
+	report := func(severity types.Severity, format string, args ...any) {
+		findings = append(findings, types.Finding{Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	checkAction := func(key string, value string) {
+		value = strings.TrimSpace(value)
+		if utils.In(strings.ToUpper(value), constraintActions...) {
+			return
+		}
+		if closest := utils.Closest(value, constraintActions...); closest != "" {
+			report(types.SeverityError, "%s value %q is not a constraint action, did you mean %s", key, value, closest)
+		} else {
+			report(types.SeverityError, "%s value %q is not a constraint action", key, value)
+		}
+	}
+
+	for _, setting := range parser.GormSettings(tag, ";") {
+		if !containsFold(gormKeys, setting.Name) {
+			if closest := utils.Closest(setting.Name, gormKeys...); closest != "" {
+				report(types.SeverityWarning, "unknown gorm key %q, did you mean %s", setting.Name, closest)
+			} else {
+				report(types.SeverityWarning, "unknown gorm key %q", setting.Name)
+			}
+			continue
+		}
+
+		switch setting.Key {
+		case "ONDELETE":
+			checkAction("OnDelete", setting.Value)
+		case "ONUPDATE":
+			checkAction("OnUpdate", setting.Value)
+		case "CONSTRAINT":
+			for _, option := range parser.GormSettings(setting.Value, ",") {
+				switch option.Key {
+				case "ONDELETE":
+					checkAction("OnDelete", option.Value)
+				case "ONUPDATE":
+					checkAction("OnUpdate", option.Value)
+				default:
+					report(types.SeverityWarning, "unknown constraint option %q", option.Name)
+				}
+			}
+		}
+	}
+	return findings
+}
+
+func typescriptFindings(tag string) []types.Finding {
+	findings := []types.Finding{}
+	for _, part := range strings.Split(tag, ";") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		key, _, _ := strings.Cut(part, "=")
+		if utils.In(key, typescriptKeys...) {
+			continue
+		}
+		message := fmt.Sprintf("unknown typescript key %q", key)
+		if closest := utils.Closest(key, typescriptKeys...); closest != "" {
+			message += ", did you mean " + closest
+		}
+		findings = append(findings, types.Finding{Severity: types.SeverityWarning, Message: message})
+	}
+	return findings
+}
+
+// validateFindings warns about the rules the generated validation does not
+// know, they may be registered with the validator of the project so they
+// are not errors.
+func validateFindings(tag string) []types.Finding {
+	findings := []types.Finding{}
+	for _, part := range strings.Split(tag, ";") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		rule, _, _ := strings.Cut(part, "=")
+		if utils.In(rule, validatorRules...) {
+			continue
+		}
+		message := fmt.Sprintf("unknown validate rule %q", part)
+		if closest := utils.Closest(rule, validatorRules...); closest != "" {
+			message += ", did you mean " + closest
+		}
+		findings = append(findings, types.Finding{Severity: types.SeverityWarning, Message: message})
+	}
+	return findings
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package gorming
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGormFindings(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want []string
+	}{
+		{`column:name;default:'a\;b'`, nil},
+		{`comment:x\;y;not null`, nil},
+		{`constraint:OnUpdate:CASCADE,OnDelete:SET NULL`, nil},
+		{`foreignkey:OwnerID;REFERENCES:ID`, nil},
+		{`foriegnKey:OwnerID`, []string{`unknown gorm key "foriegnKey", did you mean foreignKey`}},
+		{`constraint:OnDelete:SET NLL`, []string{`OnDelete value "SET NLL" is not a constraint action, did you mean SET NULL`}},
+		{`constraint:OnDelete:CASCADE,Deferrable`, []string{`unknown constraint option "Deferrable"`}},
+		{`OnUpdate:NOTHING`, []string{`OnUpdate value "NOTHING" is not a constraint action`}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.tag, func(t *testing.T) {
+			got := []string{}
+			for _, finding := range gormFindings(test.tag) {
+				got = append(got, finding.Message)
+			}
+			if test.want == nil {
+				test.want = []string{}
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
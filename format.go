@@ -0,0 +1,127 @@
+package gorming
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"path"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/oSethoum/gorming/types"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// formatGo gofmts a generated go file and removes its unused imports, the
+// imports are never resolved so the output does not depend on the machine.
+func formatGo(filename string, content []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, content, parser.ParseComments)
+	if err != nil {
+		return nil, goError(err, content)
+	}
+
+	pruneImports(fset, file)
+
+	buffer := new(bytes.Buffer)
+	if err := format.Node(buffer, fset, file); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// pruneImports deletes the imports whose name is not used in the file, an
+// import without a name is known by the last element of its path the way
+// goimports guesses it.
+func pruneImports(fset *token.FileSet, file *ast.File) {
+	used := map[string]bool{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if selector, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := selector.X.(*ast.Ident); ok && ident.Obj == nil {
+				used[ident.Name] = true
+			}
+		}
+		return true
+	})
+
+	for _, spec := range append([]*ast.ImportSpec{}, file.Imports...) {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := importName(importPath)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		if name == "_" || name == "." || used[name] {
+			continue
+		}
+		if spec.Name != nil {
+			astutil.DeleteNamedImport(fset, file, spec.Name.Name, importPath)
+		} else {
+			astutil.DeleteImport(fset, file, importPath)
+		}
+	}
+}
+
+// importName guesses the package name of an import path, a major version
+// element, a "go-" prefix and a suffix after a non identifier character
+// like in "gopkg.in/yaml.v3" are dropped.
+func importName(importPath string) string {
+	name := path.Base(importPath)
+	if strings.HasPrefix(name, "v") {
+		if _, err := strconv.Atoi(name[1:]); err == nil && path.Dir(importPath) != "." {
+			name = path.Base(path.Dir(importPath))
+		}
+	}
+	name = strings.TrimPrefix(name, "go-")
+	if i := strings.IndexFunc(name, func(r rune) bool {
+		return r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// goError points at the first syntax error of the generated content.
+func goError(err error, content []byte) error {
+	var list scanner.ErrorList
+	if !errors.As(err, &list) || len(list) == 0 {
+		return err
+	}
+
+	first := list[0]
+	lines := strings.Split(string(content), "\n")
+	source := ""
+	if first.Pos.Line > 0 && first.Pos.Line <= len(lines) {
+		source = strings.TrimSpace(lines[first.Pos.Line-1])
+	}
+
+	return fmt.Errorf("invalid go at line %d:%d: %s\n\t%s", first.Pos.Line, first.Pos.Column, first.Msg, source)
+}
+
+// invalidTable renders the template again with one table at a time and
+// returns the first table whose output is not valid go.
+func (r *renderer) invalidTable(templateName string, filename string, data types.TemplateData) string {
+	for _, table := range data.Schema.Tables {
+		schema := *data.Schema
+		schema.Tables = []types.Table{table}
+		tableData := data
+		tableData.Schema = &schema
+
+		buffer, err := r.execute(templateName, tableData, r.funcs(&tableData))
+		if err != nil {
+			continue
+		}
+		if _, err := parser.ParseFile(token.NewFileSet(), filename, buffer.Bytes(), parser.AllErrors); err != nil {
+			return table.Name
+		}
+	}
+	return ""
+}
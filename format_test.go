@@ -0,0 +1,53 @@
+package gorming
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatGoImports(t *testing.T) {
+	got, err := formatGo("db.go", []byte(`package db
+import (
+	"fmt"
+	"strings"
+	"github.com/gofiber/fiber/v2"
+	"gopkg.in/yaml.v3"
+	"github.com/mattn/go-sqlite3"
+	json "github.com/goccy/go-json"
+	gormjson "gorm.io/datatypes"
+	_ "embed"
+	"example.com/app/utils"
+)
+func run(strings []string, app *fiber.App) {
+	_ = yaml.Marshal
+	_ = sqlite3.ErrError
+	_ = json.Marshal
+	_ = strings
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, kept := range []string{`"github.com/gofiber/fiber/v2"`, `"gopkg.in/yaml.v3"`, `"github.com/mattn/go-sqlite3"`, `json "github.com/goccy/go-json"`, `_ "embed"`} {
+		if !strings.Contains(string(got), kept) {
+			t.Errorf("%s is removed\n%s", kept, got)
+		}
+	}
+	// strings is shadowed by the parameter
+	for _, removed := range []string{`"fmt"`, `"strings"`, `"gorm.io/datatypes"`, `"example.com/app/utils"`} {
+		if strings.Contains(string(got), removed) {
+			t.Errorf("%s is kept\n%s", removed, got)
+		}
+	}
+	if !strings.Contains(string(got), "func run(strings []string, app *fiber.App) {\n\t_ = yaml.Marshal") {
+		t.Errorf("not gofmt'ed\n%s", got)
+	}
+}
+
+func TestFormatGoError(t *testing.T) {
+	_, err := formatGo("db.go", []byte("package db\n\nfunc run() {\n\treturn 1 +\n}\n"))
+	if err == nil || !strings.Contains(err.Error(), "invalid go at line 5:1") {
+		t.Fatalf("got %v, want the line of the error", err)
+	}
+}
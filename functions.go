@@ -182,7 +182,117 @@ func templateFunctions(data *types.TemplateData) template.FuncMap {
 		return tableNameFunc(tableByName(name))
 	}
 
-	tsTypeFunc := func(column types.Column) string {
+	// columnName is the database column of a field, the gorm column tag or
+	// the name the default gorm naming strategy gives it, json names and
+	// Case do not change it.
+	columnNameFunc := func(column types.Column) string {
+		return utils.Choice(column.Tags.Gorm.Column, utils.DBName(column.Name))
+	}
+
+	columnNameStringFunc := func(table string, field string) string {
+		for _, t := range data.Schema.Tables {
+			if t.Name != table {
+				continue
+			}
+			for _, c := range t.Columns {
+				if c.Name == field {
+					return columnNameFunc(c)
+				}
+			}
+		}
+		return utils.DBName(field)
+	}
+
+	// embeddedColumns flattens the fields of an embedded struct column, the
+	// columns they return from columnName have the EmbeddedPrefix.
+	var embeddedColumnsFunc func(column types.Column) []types.Column
+	embeddedColumnsFunc = func(column types.Column) []types.Column {
+		flat := []types.Column{}
+		for _, field := range column.Fields {
+			if field.Tags.Gorm.Ignore {
+				continue
+			}
+			field.Tags.Gorm.Column = column.Tags.Gorm.EmbeddedPrefix + columnNameFunc(field)
+			if len(field.Fields) > 0 {
+				for _, nested := range embeddedColumnsFunc(field) {
+					nested.Tags.Gorm.Column = column.Tags.Gorm.EmbeddedPrefix + nested.Tags.Gorm.Column
+					flat = append(flat, nested)
+				}
+				continue
+			}
+			flat = append(flat, field)
+		}
+		return flat
+	}
+
+	hasEmbeddedFunc := func(table types.Table) bool {
+		for _, column := range table.Columns {
+			if len(column.Fields) > 0 && !column.Tags.Json.Ignore {
+				return true
+			}
+		}
+		return false
+	}
+
+	// joinColumns are the columns of the many2many join table that point to
+	// table and to the table of column.
+	joinColumnsFunc := func(table types.Table, column types.Column) []string {
+		return []string{
+			utils.DBName(utils.Choice(column.Tags.Gorm.JoinForeignKey, table.Name+"ID")),
+			utils.DBName(utils.Choice(column.Tags.Gorm.JoinReferences, column.RawType+"ID")),
+		}
+	}
+
+	// typeValue is the value of the type column for the rows that belong to
+	// table through a polymorphic edge.
+	typeValueFunc := func(table types.Table, column types.Column) string {
+		return utils.Choice(column.Edge.TypeValue, tableNameFunc(table))
+	}
+
+	// polymorphics are the polymorphic associations table belongs to, with
+	// the type values of every owner.
+	polymorphicsFunc := func(table types.Table) []polymorphic {
+		out := []polymorphic{}
+		for _, owner := range data.Schema.Tables {
+			for _, column := range owner.Columns {
+				edge := column.Edge
+				if edge == nil || edge.TypeKey == "" || edge.Table != table.Name {
+					continue
+				}
+
+				i := lo.IndexOf(lo.Map(out, func(p polymorphic, _ int) string { return p.Type.Name + "." + p.ID.Name }), edge.TypeKey+"."+edge.TableKey)
+				if i < 0 {
+					typeColumn, _ := lo.Find(table.Columns, func(c types.Column) bool { return c.Name == edge.TypeKey })
+					idColumn, _ := lo.Find(table.Columns, func(c types.Column) bool { return c.Name == edge.TableKey })
+					out = append(out, polymorphic{Name: edge.Polymorphic, Type: typeColumn, ID: idColumn})
+					i = len(out) - 1
+				}
+
+				value := typeValueFunc(owner, column)
+				if !lo.Contains(out[i].Values, value) {
+					out[i].Values = append(out[i].Values, value)
+				}
+			}
+		}
+		return out
+	}
+
+	polymorphicKeyFunc := func(table types.Table, column types.Column) bool {
+		return lo.ContainsBy(polymorphicsFunc(table), func(p polymorphic) bool {
+			return column.Name == p.Type.Name || column.Name == p.ID.Name
+		})
+	}
+
+	tsOptionalFunc := func(column types.Column) string {
+		if utils.In(column.Name, "DeletedAt") || strings.HasPrefix(column.Type, "*") || column.Slice || column.Tags.Typescript.Optional ||
+			len(column.Tags.Gorm.Default) > 0 {
+			return "?"
+		}
+		return ""
+	}
+
+	var tsTypeFunc func(column types.Column) string
+	tsTypeFunc = func(column types.Column) string {
 		if column.Tags.Typescript.Type != "" {
 			return column.Tags.Typescript.Type
 		}
@@ -201,6 +311,17 @@ func templateFunctions(data *types.TemplateData) template.FuncMap {
 			}
 		}
 
+		// embedded structs that are not an extra type are written in place
+		if len(column.Fields) > 0 && !found {
+			fields := []string{}
+			for _, field := range column.Fields {
+				if !field.Tags.Json.Ignore {
+					fields = append(fields, tsNameFunc(field)+tsOptionalFunc(field)+": "+tsTypeFunc(field))
+				}
+			}
+			return "{ " + strings.Join(fields, "; ") + " }"
+		}
+
 		typesMap := map[string]string{
 			"Time":   "string",
 			"bool":   "boolean",
@@ -265,14 +386,6 @@ func templateFunctions(data *types.TemplateData) template.FuncMap {
 		return ""
 	}
 
-	tsOptionalFunc := func(column types.Column) string {
-		if utils.In(column.Name, "DeletedAt") || strings.HasPrefix(column.Type, "*") || column.Slice || column.Tags.Typescript.Optional ||
-			len(column.Tags.Gorm.Default) > 0 {
-			return "?"
-		}
-		return ""
-	}
-
 	columnOptionalFunc := func(column types.Column) bool {
 		return utils.In(column.Name, "DeletedAt") || strings.HasPrefix(column.Type, "*") ||
 			len(column.Tags.Gorm.Default) > 0 || column.Slice || column.Edge != nil
@@ -304,6 +417,12 @@ func templateFunctions(data *types.TemplateData) template.FuncMap {
 		return out
 	}
 
+	// fkName is the name gorm gives the foreign key constraint of an edge,
+	// two edges to the same table get different names.
+	fkName := func(table types.Table, column types.Column) string {
+		return "fk_" + tableNameFunc(table) + "_" + utils.DBName(column.Name)
+	}
+
 	getTableFKConstraintsFunc := func(table types.Table) string {
 		ss := ""
 		edges := _getRequiredEdges(table.Columns)
@@ -323,13 +442,12 @@ func templateFunctions(data *types.TemplateData) template.FuncMap {
 				u += fmt.Sprintf(`ON DELETE %s`, v[1].Tags.Gorm.OnDelete)
 			}
 
-			s := fmt.Sprintf(`DB.Exec("ALTER TABLE %s ADD CONSTRAINT fk_%s_%s FOREIGN KEY (%s) REFERENCES %s(%s) %s")`,
-				tableNameStringFunc(table.Name),
+			s := fmt.Sprintf(`DB.Exec("ALTER TABLE %s ADD CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s(%s) %s")`,
 				tableNameStringFunc(table.Name),
+				fkName(table, v[0]),
+				columnNameStringFunc(table.Name, v[0].Edge.LocalKey),
 				tableNameStringFunc(v[0].Edge.Table),
-				tsNameStringFunc(v[0].Edge.LocalKey),
-				tableNameStringFunc(v[0].Edge.Table),
-				tsNameStringFunc(v[0].Edge.TableKey),
+				columnNameStringFunc(v[0].Edge.Table, v[0].Edge.TableKey),
 				u,
 			)
 
@@ -341,8 +459,10 @@ func templateFunctions(data *types.TemplateData) template.FuncMap {
 	}
 
 	getTableFKMigratorFunc := func(table types.Table) string {
-		ss := ""
+		drops := []string{}
+		adds := ""
 		edges := _getRequiredEdges(table.Columns)
+		_table := tableNameStringFunc(table.Name)
 
 		for _, v := range edges {
 			u := ""
@@ -358,28 +478,33 @@ func templateFunctions(data *types.TemplateData) template.FuncMap {
 			} else if v[1].Tags.Gorm.OnDelete != "" {
 				u += fmt.Sprintf(`ON DELETE %s`, v[1].Tags.Gorm.OnDelete)
 			}
-			_table := tableNameStringFunc(table.Name)
 			_targetTable := tableNameStringFunc(v[0].Edge.Table)
-			_constraint := fmt.Sprintf("fk_%s_%s", _table, _targetTable)
-
-			s := fmt.Sprintf(`if DB.Migrator().HasConstraint("%s", "%s") { 
-				DB.Migrator().DropConstraint("%s", "%s") 
-			}
-			`, _table, _constraint, _table, _constraint)
-
-			s += fmt.Sprintf(`DB.Exec("ALTER TABLE %s ADD CONSTRAINT fk_%s_%s FOREIGN KEY (%s) REFERENCES %s(%s) %s")`,
-				tableNameStringFunc(table.Name),
-				tableNameStringFunc(table.Name),
-				tableNameStringFunc(v[0].Edge.Table),
-				tsNameStringFunc(v[0].Edge.LocalKey),
-				tableNameStringFunc(v[0].Edge.Table),
-				tsNameStringFunc(v[0].Edge.TableKey),
+			_constraint := fkName(table, v[0])
+
+			// fk_<table>_<target> was the name before two edges to the
+			// same table were told apart
+			drops = append(drops, fmt.Sprintf("fk_%s_%s", _table, _targetTable), _constraint)
+
+			adds += fmt.Sprintf("DB.Exec(\"ALTER TABLE %s ADD CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s(%s) %s\")\n",
+				_table,
+				_constraint,
+				columnNameStringFunc(table.Name, v[0].Edge.LocalKey),
+				_targetTable,
+				columnNameStringFunc(v[0].Edge.Table, v[0].Edge.TableKey),
 				u,
 			)
+		}
 
-			ss += fmt.Sprintf("%s\n", s)
-
+		// every name is dropped once and before any constraint is added, so
+		// the old name of one edge cannot drop the new one of another
+		ss := ""
+		for _, name := range lo.Uniq(drops) {
+			ss += fmt.Sprintf(`if DB.Migrator().HasConstraint("%s", "%s") { 
+				DB.Migrator().DropConstraint("%s", "%s") 
+			}
+			`, _table, name, _table, name)
 		}
+		ss += adds
 
 		return ss
 	}
@@ -442,13 +567,48 @@ func templateFunctions(data *types.TemplateData) template.FuncMap {
 		return "null"
 	}
 
+	// tsDoc and goDoc render a doc comment on its own lines, indent is the
+	// indentation of the declaration that follows it.
+	tsDocFunc := func(doc string, indent string) string {
+		if doc == "" {
+			return ""
+		}
+		lines := strings.Split(strings.ReplaceAll(doc, "*/", "*\\/"), "\n")
+		if len(lines) == 1 {
+			return "/** " + lines[0] + " */\n" + indent
+		}
+		s := "/**\n"
+		for _, line := range lines {
+			s += strings.TrimRight(indent+" * "+line, " ") + "\n"
+		}
+		return s + indent + " */\n" + indent
+	}
+
+	goDocFunc := func(doc string, indent string) string {
+		if doc == "" {
+			return ""
+		}
+		s := ""
+		for _, line := range strings.Split(doc, "\n") {
+			s += strings.TrimRight("// "+line, " ") + "\n" + indent
+		}
+		return s
+	}
+
 	return template.FuncMap{
 		"plural":                inflection.Plural,
+		"tsDoc":                 tsDocFunc,
+		"goDoc":                 goDocFunc,
 		"models":                modelsFunc,
 		"tsName":                tsNameFunc,
 		"tsNameString":          tsNameStringFunc,
 		"tableName":             tableNameFunc,
 		"tableNameString":       tableNameStringFunc,
+		"columnName":            columnNameFunc,
+		"columnNameString":      columnNameStringFunc,
+		"joinColumns":           joinColumnsFunc,
+		"embeddedColumns":       embeddedColumnsFunc,
+		"hasEmbedded":           hasEmbeddedFunc,
 		"tsType":                tsTypeFunc,
 		"columnOptional":        columnOptionalFunc,
 		"columnOptionalCreate":  columnOptionalCreateFunc,
@@ -471,5 +631,17 @@ func templateFunctions(data *types.TemplateData) template.FuncMap {
 		"setNullFieldType":      setNullFieldTypeFunc,
 		"getTableFKConstraints": getTableFKConstraintsFunc,
 		"getTableFKMigrator":    getTableFKMigratorFunc,
+		"typeValue":             typeValueFunc,
+		"polymorphics":          polymorphicsFunc,
+		"polymorphicKey":        polymorphicKeyFunc,
 	}
 }
+
+// polymorphic is a polymorphic association seen from the table that has
+// its type and id columns, Values are the type values of its owners.
+type polymorphic struct {
+	Name   string
+	Type   types.Column
+	ID     types.Column
+	Values []string
+}
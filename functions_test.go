@@ -0,0 +1,63 @@
+package gorming
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/oSethoum/gorming/parser"
+	"github.com/oSethoum/gorming/types"
+)
+
+type fkUser struct {
+	ID uint `json:"id"`
+}
+
+func (fkUser) Table() string { return "users" }
+
+type fkMessage struct {
+	ID         uint   `json:"id"`
+	SenderID   uint   `json:"sender_id"`
+	Sender     fkUser `json:"sender"`
+	ReceiverID uint   `json:"receiver_id"`
+	Receiver   fkUser `json:"receiver" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+func (fkMessage) Table() string { return "messages" }
+
+func TestGetTableFKMigrator(t *testing.T) {
+	schema, err := parser.Parse([]any{fkUser{}, fkMessage{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	funcs := templateFunctions(&types.TemplateData{Schema: schema, Config: types.Config{Case: types.Snake}})
+	migrator := funcs["getTableFKMigrator"].(func(types.Table) string)
+
+	var messages types.Table
+	for _, table := range schema.Tables {
+		if table.Name == "fkMessage" {
+			messages = table
+		}
+	}
+	got := migrator(messages)
+
+	// each name is dropped once, before the constraints are added
+	for _, want := range []struct {
+		text  string
+		count int
+	}{
+		{`DB.Migrator().DropConstraint("messages", "fk_messages_users")`, 1},
+		{`DB.Migrator().DropConstraint("messages", "fk_messages_sender")`, 1},
+		{`DB.Migrator().DropConstraint("messages", "fk_messages_receiver")`, 1},
+		{`ALTER TABLE messages ADD CONSTRAINT fk_messages_sender FOREIGN KEY (sender_id) REFERENCES users(id)`, 1},
+		{`ALTER TABLE messages ADD CONSTRAINT fk_messages_receiver FOREIGN KEY (receiver_id) REFERENCES users(id) ON DELETE CASCADE`, 1},
+		{`ADD CONSTRAINT`, 2},
+		{`ADD CONSTRAINT fk_messages_users`, 0},
+	} {
+		if n := strings.Count(got, want.text); n != want.count {
+			t.Errorf("got %d times %s, want %d in\n%s", n, want.text, want.count, got)
+		}
+	}
+	if strings.LastIndex(got, "DropConstraint") > strings.Index(got, "ADD CONSTRAINT") {
+		t.Errorf("a constraint is dropped after one is added\n%s", got)
+	}
+}
@@ -1,13 +1,21 @@
 module github.com/oSethoum/gorming
 
-go 1.22
+go 1.22.0
 
 require github.com/jinzhu/inflection v1.0.0
 
 require (
+	github.com/fsnotify/fsnotify v1.9.0
 	github.com/iancoleman/strcase v0.3.0
 	github.com/rs/xid v1.6.0
 	github.com/samber/lo v1.47.0
+	golang.org/x/tools v0.29.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
-require golang.org/x/text v0.16.0 // indirect
+require (
+	golang.org/x/mod v0.22.0 // indirect
+	golang.org/x/sync v0.10.0 // indirect
+	golang.org/x/sys v0.29.0 // indirect
+	golang.org/x/text v0.16.0 // indirect
+)
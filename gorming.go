@@ -2,50 +2,265 @@ package gorming
 
 import (
 	"embed"
+	"fmt"
+	"log"
+	"os"
 	"path/filepath"
 
 	"github.com/oSethoum/gorming/parser"
 	"github.com/oSethoum/gorming/types"
+	"github.com/oSethoum/gorming/utils"
 )
 
 //go:embed templates
 var templates embed.FS
 
-func New(config types.Config) types.Engine {
-	config = defaultConfig(config)
+// Generator parses the models and renders every template, collecting all
+// problems into a types.Errors instead of stopping on the first one.
+type Generator struct {
+	config  types.Config
+	targets []types.Target
+}
+
+func NewGenerator(config types.Config, plugins ...types.Plugin) *Generator {
+	config.Plugins = append(config.Plugins, plugins...)
+	return &Generator{config: config}
+}
+
+// Register adds extra files rendered after the built-in ones, with the
+// same data and the same Files/FilesAction rules.
+func (g *Generator) Register(targets ...types.Target) *Generator {
+	g.targets = append(g.targets, targets...)
+	return g
+}
+
+// New returns an engine that exits the program on the first failed run,
+// use NewGenerator to handle the errors yourself.
+func New(config types.Config, plugins ...types.Plugin) types.Engine {
+	generator := NewGenerator(config, plugins...)
 	return func(tables []any, Types ...any) {
-		schema := parser.Parse(tables, Types...)
+		if err := generator.Generate(tables, Types...); err != nil {
+			log.Fatalln(err)
+		}
+	}
+}
 
-		if config.Debug {
-			writeJSON("schema.json", schema)
+// Generate renders every template and writes the result, when
+// Config.DryRun or Config.Diff is set the changes are printed instead and
+// Config.Check turns stale files into an error. Config.Report prints the
+// changes and writes them, Config.Doctor only prints the findings. With no
+// tables and Config.Groups set every group is generated.
+func (g *Generator) Generate(tables []any, Types ...any) error {
+	config, err := defaultConfig(g.config)
+	if err == nil && len(tables) == 0 && len(config.Groups) > 0 {
+		return g.GenerateGroups(config.Groups...)
+	}
+
+	if err == nil && config.Doctor {
+		findings, err := doctor(config, tables, Types...)
+		if err != nil {
+			return err
 		}
+		printFindings(os.Stdout, findings)
+		return doctorError(findings)
+	}
 
-		data := types.TemplateData{
-			Schema: schema,
-			Config: config,
+	config, outputs, err := g.render(tables, Types...)
+	if err != nil {
+		return err
+	}
+	return finish(config, outputs)
+}
+
+// finish checks, prints or writes the rendered outputs depending on the
+// mode of config.
+func finish(config types.Config, outputs []output) error {
+	if config.Check {
+		return checkOutputs(config, outputs)
+	}
+
+	if config.DryRun || config.Diff {
+		changes, err := planOutputs(config, outputs)
+		printChanges(os.Stdout, changes, config.Diff)
+		return err
+	}
+
+	var changes []types.Change
+	var err error
+	if config.Report {
+		changes, err = planOutputs(config, outputs)
+		if err != nil {
+			return err
 		}
+	}
+
+	m, err := readManifest(config)
+	if err != nil {
+		return err
+	}
+
+	edited := editedFiles(config, m)
+
+	errs := types.Errors{}
+	errs.Add(writeOutputs(config.Plugins, outputs))
+	errs.Add(syncManifest(config, m, edited, outputs))
+	if config.Report {
+		printChanges(os.Stdout, changes, false)
+	}
+	return errs.Err()
+}
+
+// Plan renders every template in memory and returns the files that would
+// be created or updated on disk, nothing is written.
+func (g *Generator) Plan(tables []any, Types ...any) ([]types.Change, error) {
+	config, outputs, err := g.render(tables, Types...)
+	if err != nil {
+		return nil, err
+	}
+	return planOutputs(config, outputs)
+}
+
+// Check renders every template in memory and returns an error when a
+// generated file is missing or differs from the one on disk.
+func (g *Generator) Check(tables []any, Types ...any) error {
+	config, outputs, err := g.render(tables, Types...)
+	if err != nil {
+		return err
+	}
+	return checkOutputs(config, outputs)
+}
+
+func (g *Generator) render(tables []any, Types ...any) (types.Config, []output, error) {
+	config, err := defaultConfig(g.config)
+	if err != nil {
+		errs := types.Errors{}
+		errs.Add(err)
+		return config, nil, errs
+	}
+	config, outputs, err := g.renderConfig(config, tables, Types...)
+	if err != nil {
+		return config, nil, err
+	}
+	if err := preserveUserCode(config, outputs); err != nil {
+		return config, nil, err
+	}
+	return config, outputs, nil
+}
+
+// Render renders every template in memory and returns the files by their
+// path relative to Paths.BasePath. Nothing is written and the files on disk
+// are not read, so user regions and Once files have their template content.
+func (g *Generator) Render(tables []any, Types ...any) (types.Files, error) {
+	config, err := defaultConfig(g.config)
+	if err != nil {
+		return nil, err
+	}
+	config, outputs, err := g.renderConfig(config, tables, Types...)
+	if err != nil {
+		return nil, err
+	}
+	files := types.Files{}
+	for _, o := range outputs {
+		files[relativePath(config.Paths.BasePath, o.path)] = o.content
+	}
+	return files, nil
+}
+
+// renderConfig renders with a config that has its defaults already, the
+// files on disk are not read.
+func (g *Generator) renderConfig(config types.Config, tables []any, Types ...any) (types.Config, []output, error) {
+	errs := types.Errors{}
+	outputs := []output{}
+
+	schema, err := parse(config, tables, Types...)
+	errs.Add(err)
 
-		writeTemplate("common/db", filepath.Join(config.Paths.BackendPath, "db/db.go"), data, types.FileDB)
-		writeTemplate("common/migration", filepath.Join(config.Paths.BackendPath, "db/migration.go"), data, types.FileMigration)
-		if config.DBKind == types.MySQL {
-			writeTemplate("common/m_query", filepath.Join(config.Paths.BackendPath, "db/query.go"), data, types.FileQuery)
-		} else {
-			writeTemplate("common/query", filepath.Join(config.Paths.BackendPath, "db/query.go"), data, types.FileQuery)
+	if len(errs) > 0 {
+		return config, nil, errs
+	}
+
+	for _, plugin := range config.Plugins {
+		if p, ok := plugin.(types.SchemaPlugin); ok {
+			if err := p.AfterParse(schema, &config); err != nil {
+				errs.Add(pluginError(plugin, err))
+			}
 		}
-		writeTemplate("common/schema", filepath.Join(config.Paths.BackendPath, "db/schema.go"), data, types.FileSchema)
-		writeTemplate("common/hooks", filepath.Join(config.Paths.BackendPath, "db/hooks.go"), data, types.FileHooks)
-		writeTemplate("common/utils", filepath.Join(config.Paths.BackendPath, "utils/utils.go"), data, types.FileUtils)
-		writeTemplate("common/error", filepath.Join(config.Paths.BackendPath, "handlers/error.go"), data, types.FileError)
-		writeTemplate("server/handler", filepath.Join(config.Paths.BackendPath, "handlers/handler.go"), data, types.FileHandler)
-		writeTemplate("server/response", filepath.Join(config.Paths.BackendPath, "handlers/response.go"), data, types.FileResponse)
-		writeTemplate("server/ws", filepath.Join(config.Paths.BackendPath, "handlers/ws.go"), data, types.FileWs)
-		writeTemplate("server/routes", filepath.Join(config.Paths.BackendPath, "routes/routes.go"), data, types.FileRoutes)
-
-		for _, v := range data.Config.Paths.TypescriptClient {
-			writeTemplate("client/api", filepath.Join(v, "api.ts"), data, types.FileTsApi)
-			writeTemplate("client/types", filepath.Join(v, "types.ts"), data, types.FileTsTypes)
-			writeTemplate("client/event", filepath.Join(v, "event.ts"), data, types.FileTsEvent)
-			writeTemplate("client/request", filepath.Join(v, "request.ts"), data, types.FileRequest)
+	}
+
+	if len(errs) > 0 {
+		return config, nil, errs
+	}
+
+	if config.Debug {
+		errs.Add(renderJSON(&outputs, filepath.Join(config.Paths.BasePath, "schema.json"), schema))
+	}
+
+	data := types.TemplateData{
+		Schema: schema,
+		Config: config,
+	}
+
+	rendered, err := newRenderer(config.Templates).render(append(builtinTargets(config), g.targets...), data)
+	errs.Add(err)
+	outputs = append(outputs, rendered...)
+
+	if len(errs) > 0 {
+		return config, nil, errs
+	}
+
+	return config, outputs, nil
+}
+
+func builtinTargets(config types.Config) []types.Target {
+	backend := config.Paths.BackendPath
+	query := "common/query"
+	if config.DBKind == types.MySQL {
+		query = "common/m_query"
+	}
+
+	targets := []types.Target{
+		{Template: "common/db", Path: filepath.Join(backend, "db/db.go"), File: types.FileDB},
+		{Template: "common/migration", Path: filepath.Join(backend, "db/migration.go"), File: types.FileMigration},
+		{Template: query, Path: filepath.Join(backend, "db/query.go"), File: types.FileQuery},
+		{Template: "common/schema", Path: filepath.Join(backend, "db/schema.go"), File: types.FileSchema},
+		{Template: "common/hooks", Path: filepath.Join(backend, "db/hooks.go"), File: types.FileHooks},
+		{Template: "common/utils", Path: filepath.Join(backend, "utils/utils.go"), File: types.FileUtils},
+		{Template: "common/error", Path: filepath.Join(backend, "handlers/error.go"), File: types.FileError},
+		{Template: "server/handler", Path: filepath.Join(backend, "handlers/handler.go"), File: types.FileHandler},
+		{Template: "server/response", Path: filepath.Join(backend, "handlers/response.go"), File: types.FileResponse},
+		{Template: "server/ws", Path: filepath.Join(backend, "handlers/ws.go"), File: types.FileWs},
+		{Template: "server/routes", Path: filepath.Join(backend, "routes/routes.go"), File: types.FileRoutes},
+	}
+
+	for _, v := range config.Paths.TypescriptClient {
+		targets = append(targets,
+			types.Target{Template: "client/api", Path: filepath.Join(v, "api.ts"), File: types.FileTsApi},
+			types.Target{Template: "client/types", Path: filepath.Join(v, "types.ts"), File: types.FileTsTypes},
+			types.Target{Template: "client/event", Path: filepath.Join(v, "event.ts"), File: types.FileTsEvent},
+			types.Target{Template: "client/request", Path: filepath.Join(v, "request.ts"), File: types.FileRequest},
+		)
+	}
+
+	return targets
+}
+
+// parse reads the models from the tables or from the source of
+// Config.Models when no tables are passed.
+func parse(config types.Config, tables []any, Types ...any) (*types.Schema, error) {
+	if len(tables) == 0 && len(config.Models) > 0 {
+		root, _, err := utils.CurrentGoMod()
+		if err != nil {
+			return nil, &types.Error{Err: err}
 		}
+		return parser.ParseSource(root, config.Models)
+	}
+	return parser.Parse(tables, Types...)
+}
+
+func pluginError(plugin types.Plugin, err error) error {
+	if e, ok := err.(*types.Error); ok {
+		e.Err = fmt.Errorf("plugin %s: %w", plugin.Name(), e.Err)
+		return e
 	}
+	return &types.Error{Err: fmt.Errorf("plugin %s: %w", plugin.Name(), err)}
 }
@@ -0,0 +1,180 @@
+package gorming
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/oSethoum/gorming/types"
+	"github.com/oSethoum/gorming/utils"
+)
+
+// GenerateGroups renders every group as its own project and writes them
+// all, nothing is written when a group fails to render or when two groups
+// generate the same file.
+func (g *Generator) GenerateGroups(groups ...types.Group) error {
+	base, err := defaultConfig(g.config)
+	if err != nil {
+		return err
+	}
+
+	configs, err := groupConfigs(base, groups)
+	if err != nil {
+		return err
+	}
+
+	if base.Doctor {
+		errs := types.Errors{}
+		count := 0
+		for i, group := range groups {
+			findings, err := doctor(configs[i], group.Tables, group.Types...)
+			if err != nil {
+				errs.Add(groupError(group.Name, err))
+				continue
+			}
+			fmt.Printf("gorming: group %s\n", group.Name)
+			printFindings(os.Stdout, findings)
+			if doctorError(findings) != nil {
+				count++
+			}
+		}
+		if count > 0 {
+			errs.Add(&types.Error{Err: fmt.Errorf("doctor found errors in %d groups", count)})
+		}
+		return errs.Err()
+	}
+
+	errs := types.Errors{}
+	rendered := make([][]output, len(groups))
+	for i, group := range groups {
+		generator := &Generator{config: configs[i], targets: g.targets}
+		configs[i], rendered[i], err = generator.renderConfig(configs[i], group.Tables, group.Types...)
+		if err == nil {
+			err = preserveUserCode(configs[i], rendered[i])
+		}
+		errs.Add(groupError(group.Name, err))
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	// every group writes its own files, a shared db package or manifest
+	// would be overwritten by the last group
+	owners := map[string]string{}
+	reported := map[[2]string]bool{}
+	for i, group := range groups {
+		paths := []string{manifestPath(configs[i])}
+		for _, o := range rendered[i] {
+			paths = append(paths, o.path)
+		}
+		for _, p := range paths {
+			owner, ok := owners[p]
+			if !ok {
+				owners[p] = group.Name
+				continue
+			}
+			if !reported[[2]string{owner, group.Name}] {
+				reported[[2]string{owner, group.Name}] = true
+				errs.Add(&types.Error{Path: p, Err: fmt.Errorf("generated by groups %s and %s, set a different backend_path", owner, group.Name)})
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	for i, group := range groups {
+		errs.Add(groupError(group.Name, finish(configs[i], rendered[i])))
+	}
+	return errs.Err()
+}
+
+// groupConfigs merges every group over the base config. A group does not
+// inherit the models of the base, its typescript clients go to a folder
+// named after it and it has its own manifest.
+func groupConfigs(base types.Config, groups []types.Group) ([]types.Config, error) {
+	errs := types.Errors{}
+	names := map[string]bool{}
+	for _, group := range groups {
+		switch {
+		case group.Name == "":
+			errs.Add(&types.Error{Err: fmt.Errorf("a group has no name")})
+		case names[group.Name]:
+			errs.Add(&types.Error{Group: group.Name, Err: fmt.Errorf("the name is used by two groups")})
+		case len(group.Tables) == 0 && len(group.Models) == 0:
+			errs.Add(&types.Error{Group: group.Name, Err: fmt.Errorf("the group has no tables and no models")})
+		}
+		names[group.Name] = true
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	root, pkg, err := utils.CurrentGoMod()
+	inherited := base
+	inherited.Models = nil
+	inherited.Groups = nil
+
+	configs := []types.Config{}
+	for _, group := range groups {
+		config := group.Config
+		config.Groups = nil
+		if config.Paths.BasePath != "" && !filepath.IsAbs(config.Paths.BasePath) {
+			config.Paths.BasePath = filepath.Join(base.Paths.BasePath, config.Paths.BasePath)
+		}
+		if config.Package == "" && config.Paths.BackendPath != "" {
+			config.Package = groupPackage(base, config, root, pkg, err)
+		}
+		if len(config.Paths.TypescriptClient) == 0 {
+			for _, v := range base.Paths.TypescriptClient {
+				config.Paths.TypescriptClient = append(config.Paths.TypescriptClient, filepath.Join(v, group.Name))
+			}
+		}
+		config.Paths.Manifest = utils.Choice(config.Paths.Manifest, "gorming."+group.Name+".manifest.json")
+		config.Models = resolveModels(pkg, config.Models)
+		configs = append(configs, mergeConfig(config, inherited))
+	}
+
+	// two groups in one package would share its db and handlers
+	packages := map[string]string{}
+	for i, group := range groups {
+		if other, ok := packages[configs[i].Package]; ok {
+			errs.Add(&types.Error{Group: group.Name, Err: fmt.Errorf("package %s is also used by group %s, set a different backend_path or package", configs[i].Package, other)})
+			continue
+		}
+		packages[configs[i].Package] = group.Name
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return configs, nil
+}
+
+// groupPackage is the import path of the backend_path of a group, the
+// module path joined with its folder in the module. Without a module the
+// package of the base config is used as the root.
+func groupPackage(base types.Config, config types.Config, root string, module string, err error) string {
+	backend := filepath.ToSlash(config.Paths.BackendPath)
+	if err != nil {
+		return path.Join(base.Package, backend)
+	}
+	basePath := utils.Choice(config.Paths.BasePath, base.Paths.BasePath)
+	if rel, err := filepath.Rel(root, basePath); err == nil && !strings.HasPrefix(rel, "..") {
+		return path.Join(module, filepath.ToSlash(rel), backend)
+	}
+	return path.Join(module, backend)
+}
+
+// groupError marks the errors of a group with its name.
+func groupError(name string, err error) error {
+	errs := types.Errors{}
+	errs.Add(err)
+	for _, e := range errs {
+		if e.Group == "" {
+			e.Group = name
+		}
+	}
+	return errs.Err()
+}
@@ -0,0 +1,208 @@
+package gorming
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+
+	"github.com/oSethoum/gorming/types"
+	"github.com/oSethoum/gorming/utils"
+)
+
+type manifestFile struct {
+	Path string `json:"path"`
+	Hash string `json:"hash"`
+}
+
+// manifest lists the files written by the last run, paths are relative to
+// Paths.BasePath.
+type manifest struct {
+	Version string         `json:"version"`
+	Files   []manifestFile `json:"files"`
+}
+
+func version() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "(devel)"
+	}
+	if info.Main.Path == "github.com/oSethoum/gorming" {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == "github.com/oSethoum/gorming" {
+			return dep.Version
+		}
+	}
+	return "(devel)"
+}
+
+// hash ignores the body of user regions.
+func hash(content []byte) string {
+	sum := sha256.Sum256([]byte(stripRegions(string(content))))
+	return hex.EncodeToString(sum[:])
+}
+
+func manifestPath(config types.Config) string {
+	if filepath.IsAbs(config.Paths.Manifest) {
+		return config.Paths.Manifest
+	}
+	return filepath.Join(config.Paths.BasePath, config.Paths.Manifest)
+}
+
+func relativePath(basePath string, outPath string) string {
+	if rel, err := filepath.Rel(basePath, outPath); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return outPath
+}
+
+func absolutePath(basePath string, outPath string) string {
+	if filepath.IsAbs(outPath) {
+		return outPath
+	}
+	return filepath.Join(basePath, filepath.FromSlash(outPath))
+}
+
+func readManifest(config types.Config) (*manifest, error) {
+	m := &manifest{}
+	file, err := os.ReadFile(manifestPath(config))
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, &types.Error{Path: manifestPath(config), Err: err}
+	}
+	if err := json.Unmarshal(file, m); err != nil {
+		return nil, &types.Error{Path: manifestPath(config), Err: err}
+	}
+	return m, nil
+}
+
+func writeManifest(config types.Config, outputs []output, kept []manifestFile) error {
+	m := manifest{Version: version(), Files: kept}
+	for _, o := range outputs {
+		m.Files = append(m.Files, manifestFile{
+			Path: relativePath(config.Paths.BasePath, o.path),
+			Hash: hash(o.content),
+		})
+	}
+
+	buffer := new(bytes.Buffer)
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(m); err != nil {
+		return &types.Error{Path: manifestPath(config), Err: err}
+	}
+	return writeFile(manifestPath(config), buffer.Bytes())
+}
+
+// editedFiles returns the files of the last run whose content on disk no
+// longer matches the recorded hash.
+func editedFiles(config types.Config, m *manifest) map[string]bool {
+	edited := map[string]bool{}
+	for _, f := range m.Files {
+		content, err := os.ReadFile(absolutePath(config.Paths.BasePath, f.Path))
+		if err == nil && hash(content) != f.Hash {
+			edited[f.Path] = true
+		}
+	}
+	return edited
+}
+
+// staleFiles returns the files of the last run that are no longer generated
+// and still exist on disk.
+func staleFiles(config types.Config, m *manifest, outputs []output) []string {
+	generated := map[string]bool{}
+	for _, o := range outputs {
+		generated[relativePath(config.Paths.BasePath, o.path)] = true
+	}
+
+	stale := []string{}
+	for _, f := range m.Files {
+		if generated[f.Path] {
+			continue
+		}
+		if _, err := os.Stat(absolutePath(config.Paths.BasePath, f.Path)); err == nil {
+			stale = append(stale, f.Path)
+		}
+	}
+	return stale
+}
+
+// syncManifest warns about hand-edited files, removes the stale ones when
+// Config.Clean is set and records the new outputs, edited has to be taken
+// before the outputs are written.
+func syncManifest(config types.Config, m *manifest, edited map[string]bool, outputs []output) error {
+	errs := types.Errors{}
+
+	for _, o := range outputs {
+		if o.file != 0 && utils.In(o.file, config.Once...) {
+			continue
+		}
+		if name := relativePath(config.Paths.BasePath, o.path); edited[name] {
+			log.Printf("gorming: %s was edited by hand since the last run and has been overwritten\n", name)
+		}
+	}
+
+	// stale files left on disk stay in the manifest until they are removed
+	kept := []manifestFile{}
+	hashes := map[string]string{}
+	for _, f := range m.Files {
+		hashes[f.Path] = f.Hash
+	}
+
+	for _, name := range staleFiles(config, m, outputs) {
+		switch {
+		case edited[name]:
+			log.Printf("gorming: %s is no longer generated but was edited by hand, keeping it\n", name)
+		case config.Clean:
+			if err := os.Remove(absolutePath(config.Paths.BasePath, name)); err != nil {
+				errs.Add(&types.Error{Path: name, Err: err})
+			}
+			continue
+		default:
+			log.Printf("gorming: %s is no longer generated, set Clean to delete it\n", name)
+		}
+		kept = append(kept, manifestFile{Path: name, Hash: hashes[name]})
+	}
+
+	errs.Add(writeManifest(config, outputs, kept))
+	return errs.Err()
+}
+
+func planDeletes(config types.Config, outputs []output) ([]types.Change, error) {
+	if !config.Clean {
+		return nil, nil
+	}
+
+	m, err := readManifest(config)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []types.Change{}
+	edited := editedFiles(config, m)
+	for _, name := range staleFiles(config, m, outputs) {
+		if edited[name] {
+			continue
+		}
+		content, err := os.ReadFile(absolutePath(config.Paths.BasePath, name))
+		if err != nil {
+			return nil, &types.Error{Path: name, Err: err}
+		}
+		changes = append(changes, types.Change{
+			Path:   name,
+			Action: types.ChangeDelete,
+			Diff:   utils.UnifiedDiff("a/"+name, "/dev/null", content, nil),
+		})
+	}
+	return changes, nil
+}
@@ -0,0 +1,234 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/oSethoum/gorming/types"
+)
+
+// GormSetting is a key of a gorm tag with its value, Key is upper cased as
+// gorm reads it and Name is the key as written.
+type GormSetting struct {
+	Key   string
+	Name  string
+	Value string
+}
+
+// GormSettings splits a gorm tag, or with sep "," the options of one of
+// its keys, the way gorm does: a flag has its key as value and a separator
+// escaped with \ is kept.
+func GormSettings(tag string, sep string) []GormSetting {
+	settings := []GormSetting{}
+	parts := strings.Split(tag, sep)
+	for i := 0; i < len(parts); i++ {
+		part := parts[i]
+		for strings.HasSuffix(part, `\`) && i+1 < len(parts) {
+			i++
+			part = strings.TrimSuffix(part, `\`) + sep + parts[i]
+		}
+
+		name, value, found := strings.Cut(part, ":")
+		name = strings.TrimSpace(name)
+		key := strings.ToUpper(name)
+		if key == "" {
+			continue
+		}
+		if !found {
+			value = key
+		}
+		settings = append(settings, GormSetting{Key: key, Name: name, Value: strings.TrimSpace(value)})
+	}
+	return settings
+}
+
+// truth reports whether a flag value is set, only false turns it off.
+func truth(value string) bool {
+	return value != "" && !strings.EqualFold(value, "false")
+}
+
+func gormTag(tag string) (types.GormTag, []error) {
+	gormTag := types.GormTag{Settings: map[string]string{}}
+	errs := []error{}
+
+	number := func(key string, value string) int {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("gorm %s %q is not a number", strings.ToLower(key), value))
+		}
+		return n
+	}
+
+	for _, setting := range GormSettings(tag, ";") {
+		key, value := setting.Key, setting.Value
+		gormTag.Settings[key] = value
+
+		switch key {
+		case "COLUMN":
+			gormTag.Column = value
+		case "TYPE":
+			gormTag.Type = value
+		case "SERIALIZER":
+			gormTag.Serializer = value
+		case "SIZE":
+			gormTag.Size = number(key, value)
+		case "PRECISION":
+			gormTag.Precision = number(key, value)
+		case "SCALE":
+			gormTag.Scale = number(key, value)
+		case "PRIMARYKEY", "PRIMARY_KEY":
+			gormTag.PrimaryKey = truth(value)
+		case "UNIQUE":
+			gormTag.Unique = truth(value)
+		case "NOT NULL", "NOTNULL":
+			gormTag.NotNull = truth(value)
+		case "DEFAULT":
+			gormTag.Default = value
+		case "AUTOINCREMENT":
+			gormTag.AutoIncrement = truth(value)
+		case "AUTOINCREMENTINCREMENT":
+			gormTag.AutoIncrementIncrement = number(key, value)
+		case "AUTOCREATETIME":
+			gormTag.AutoCreateTime, gormTag.AutoCreateTimeUnit = timeTracking(value)
+		case "AUTOUPDATETIME":
+			gormTag.AutoUpdateTime, gormTag.AutoUpdateTimeUnit = timeTracking(value)
+		case "INDEX", "UNIQUEINDEX":
+			index, err := gormIndex(key, value)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			gormTag.Indexes = append(gormTag.Indexes, index)
+		case "CHECK":
+			gormTag.Check = value
+			if name, constraint, ok := strings.Cut(value, ","); ok && checkName(name) {
+				gormTag.CheckName, gormTag.Check = name, constraint
+			}
+		case "COMMENT":
+			gormTag.Comment = value
+		case "EMBEDDED":
+			gormTag.Embedded = truth(value)
+		case "EMBEDDEDPREFIX":
+			gormTag.EmbeddedPrefix = value
+		case "FOREIGNKEY":
+			gormTag.ForeignKey = value
+		case "REFERENCES":
+			gormTag.References = value
+		case "JOINFOREIGNKEY":
+			gormTag.JoinForeignKey = value
+		case "JOINREFERENCES":
+			gormTag.JoinReferences = value
+		case "MANY2MANY":
+			gormTag.Many2Many = value
+		case "POLYMORPHIC":
+			gormTag.Polymorphic = value
+		case "POLYMORPHICTYPE":
+			gormTag.PolymorphicType = value
+		case "POLYMORPHICID":
+			gormTag.PolymorphicID = value
+		case "POLYMORPHICVALUE":
+			gormTag.PolymorphicValue = value
+		case "ONDELETE":
+			gormTag.OnDelete = strings.ToUpper(value)
+		case "ONUPDATE":
+			gormTag.OnUpdate = strings.ToUpper(value)
+		case "CONSTRAINT":
+			for _, option := range GormSettings(value, ",") {
+				switch option.Key {
+				case "ONDELETE":
+					gormTag.OnDelete = strings.ToUpper(option.Value)
+				case "ONUPDATE":
+					gormTag.OnUpdate = strings.ToUpper(option.Value)
+				}
+			}
+		case "-":
+			switch strings.ToLower(value) {
+			case "-", "all":
+				gormTag.Ignore = true
+				gormTag.IgnoreMigration = strings.EqualFold(value, "all")
+			case "migration":
+				gormTag.IgnoreMigration = true
+			}
+		case "->":
+			gormTag.Read = strings.ToLower(value)
+		case "<-":
+			gormTag.Write = strings.ToLower(value)
+		}
+	}
+	return gormTag, errs
+}
+
+// timeTracking returns whether autoCreateTime or autoUpdateTime is on and
+// its unit, milli or nano, empty for the default one.
+func timeTracking(value string) (bool, string) {
+	switch strings.ToLower(value) {
+	case "false":
+		return false, ""
+	case "milli", "nano":
+		return true, strings.ToLower(value)
+	}
+	return true, ""
+}
+
+// gormIndex parses index:name,option:value,... and uniqueIndex with the
+// same options.
+func gormIndex(key string, value string) (types.GormIndex, error) {
+	index := types.GormIndex{Unique: key == "UNIQUEINDEX"}
+	if value == key {
+		return index, nil
+	}
+
+	name, options, _ := strings.Cut(value, ",")
+	index.Name = strings.TrimSpace(name)
+	var err error
+	for _, option := range GormSettings(options, ",") {
+		switch option.Key {
+		case "UNIQUE":
+			index.Unique = true
+		case "CLASS":
+			index.Class = option.Value
+			index.Unique = index.Unique || strings.EqualFold(option.Value, "UNIQUE")
+		case "TYPE":
+			index.Type = option.Value
+		case "WHERE":
+			index.Where = option.Value
+		case "COMMENT":
+			index.Comment = option.Value
+		case "OPTION":
+			index.Option = option.Value
+		case "EXPRESSION":
+			index.Expression = option.Value
+		case "SORT":
+			index.Sort = option.Value
+		case "COLLATE":
+			index.Collate = option.Value
+		case "COMPOSITE":
+			index.Composite = option.Value
+		case "LENGTH", "PRIORITY":
+			n, e := strconv.Atoi(option.Value)
+			if e != nil {
+				err = fmt.Errorf("gorm index %s %q is not a number", strings.ToLower(option.Key), option.Value)
+			}
+			if option.Key == "LENGTH" {
+				index.Length = n
+			} else {
+				index.Priority = n
+			}
+		}
+	}
+	return index, err
+}
+
+// checkName reports whether the first part of check:name,constraint is a
+// constraint name the way gorm tells them apart.
+func checkName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,86 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/oSethoum/gorming/types"
+)
+
+func TestGormTag(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want types.GormTag
+		err  bool
+	}{
+		{tag: `column:note;comment:a\;b;size:10`, want: types.GormTag{Column: "note", Comment: "a;b", Size: 10}},
+		{tag: `constraint:OnUpdate:CASCADE,OnDelete:SET NULL`, want: types.GormTag{OnUpdate: "CASCADE", OnDelete: "SET NULL"}},
+		{tag: `OnDelete:cascade`, want: types.GormTag{OnDelete: "CASCADE"}},
+		{tag: `index:idx_name,unique,sort:desc`, want: types.GormTag{Indexes: []types.GormIndex{{Name: "idx_name", Unique: true, Sort: "desc"}}}},
+		{tag: `index:,class:FULLTEXT,comment:hello \, world,where:age > 10`, want: types.GormTag{Indexes: []types.GormIndex{{Class: "FULLTEXT", Comment: "hello , world", Where: "age > 10"}}}},
+		{tag: `index:idx_member,priority:2,length:10`, want: types.GormTag{Indexes: []types.GormIndex{{Name: "idx_member", Priority: 2, Length: 10}}}},
+		{tag: `uniqueIndex`, want: types.GormTag{Indexes: []types.GormIndex{{Unique: true}}}},
+		{tag: `uniqueIndex:idx_email;index`, want: types.GormTag{Indexes: []types.GormIndex{{Name: "idx_email", Unique: true}, {}}}},
+		{tag: `check:name,age > 1`, want: types.GormTag{CheckName: "name", Check: "age > 1"}},
+		{tag: `check:age > 1`, want: types.GormTag{Check: "age > 1"}},
+		{tag: `-:migration`, want: types.GormTag{IgnoreMigration: true}},
+		{tag: `-`, want: types.GormTag{Ignore: true}},
+		{tag: `-:all`, want: types.GormTag{Ignore: true, IgnoreMigration: true}},
+		{tag: `->:false`, want: types.GormTag{Read: "false"}},
+		{tag: `<-:create`, want: types.GormTag{Write: "create"}},
+		{tag: `autoCreateTime:milli`, want: types.GormTag{AutoCreateTime: true, AutoCreateTimeUnit: "milli"}},
+		{tag: `autoUpdateTime`, want: types.GormTag{AutoUpdateTime: true}},
+		{tag: `autoUpdateTime:false`, want: types.GormTag{}},
+		{tag: `not null;primaryKey;autoIncrement:false`, want: types.GormTag{NotNull: true, PrimaryKey: true}},
+		{tag: `type:decimal(10,2);precision:10;scale:2;default:0`, want: types.GormTag{Type: "decimal(10,2)", Precision: 10, Scale: 2, Default: "0"}},
+		{tag: `embedded;embeddedPrefix:addr_`, want: types.GormTag{Embedded: true, EmbeddedPrefix: "addr_"}},
+		{tag: `foreignKey:OwnerID;references:Code;many2many:user_langs;joinForeignKey:UserID;joinReferences:LangID`, want: types.GormTag{ForeignKey: "OwnerID", References: "Code", Many2Many: "user_langs", JoinForeignKey: "UserID", JoinReferences: "LangID"}},
+		{tag: `polymorphic:Owner;polymorphicValue:master`, want: types.GormTag{Polymorphic: "Owner", PolymorphicValue: "master"}},
+		{tag: `size:abc`, err: true},
+		{tag: `index:idx,priority:high`, want: types.GormTag{Indexes: []types.GormIndex{{Name: "idx"}}}, err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.tag, func(t *testing.T) {
+			got, errs := gormTag(test.tag)
+			if (len(errs) > 0) != test.err {
+				t.Fatalf("got errors %v, want errors %v", errs, test.err)
+			}
+			got.Settings = nil
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("got %+v\nwant %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestGormTagSettings(t *testing.T) {
+	got, _ := gormTag(`Column:name; serializer:json ;NOT NULL`)
+	want := map[string]string{"COLUMN": "name", "SERIALIZER": "json", "NOT NULL": "NOT NULL"}
+	if !reflect.DeepEqual(got.Settings, want) {
+		t.Fatalf("got %v, want %v", got.Settings, want)
+	}
+}
+
+func TestGormTagPermissions(t *testing.T) {
+	tests := []struct {
+		tag                            string
+		readable, creatable, updatable bool
+	}{
+		{``, true, true, true},
+		{`->:false`, false, false, false},
+		{`->`, true, false, false},
+		{`<-:create`, true, true, false},
+		{`<-:update`, true, false, true},
+		{`<-`, true, true, true},
+		{`->;<-:create`, true, true, false},
+		{`-`, false, false, false},
+	}
+
+	for _, test := range tests {
+		tag, _ := gormTag(test.tag)
+		if tag.Readable() != test.readable || tag.Creatable() != test.creatable || tag.Updatable() != test.updatable {
+			t.Errorf("%q: got read %v create %v update %v", test.tag, tag.Readable(), tag.Creatable(), tag.Updatable())
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package parser
+
+import (
+	"reflect"
+
+	"github.com/oSethoum/gorming/types"
+)
+
+// model is a struct seen by the parser, it is built from reflection or
+// from the package source so both end up in the same schema. Doc comments
+// are only known from the source.
+type model struct {
+	name   string
+	table  string
+	doc    string
+	fields []modelField
+	byName map[string]modelField
+}
+
+type modelField struct {
+	name string
+	typ  string
+	tag  reflect.StructTag
+	doc  string
+	// fields of a struct with the gorm embedded tag
+	fields []modelField
+	// embeddedPrefix of the anonymous structs the field is promoted from
+	prefix string
+}
+
+type models map[string]*model
+
+func newModel(name string) *model {
+	return &model{name: name, byName: map[string]modelField{}}
+}
+
+// add keeps the position of the first field with the same name and the
+// value of the last one, like the embedded structs flattening did.
+func (m *model) add(f modelField) {
+	if _, ok := m.byName[f.name]; !ok {
+		m.fields = append(m.fields, f)
+	} else {
+		for i := range m.fields {
+			if m.fields[i].name == f.name {
+				m.fields[i] = f
+			}
+		}
+	}
+	m.byName[f.name] = f
+}
+
+func (m *model) has(name string) bool {
+	_, ok := m.byName[name]
+	return ok
+}
+
+func reflectModel(name string, value reflect.Value) *model {
+	m := newModel(name)
+	addReflectFields(m, value.Type(), "")
+
+	if _, ok := value.Type().MethodByName("Table"); ok {
+		m.table = value.MethodByName("Table").Call(nil)[0].String()
+	}
+	return m
+}
+
+// addReflectFields adds the fields of s to m, the fields of anonymous
+// structs, pointers or not, take the place of the embedding and keep its
+// embeddedPrefix like gorm stores them.
+func addReflectFields(m *model, s reflect.Type, prefix string) {
+	for i := 0; i < s.NumField(); i++ {
+		f := s.Field(i)
+		t := structType(f.Type)
+		if t != nil && f.Anonymous {
+			addReflectFields(m, t, prefix+embeddedPrefix(f.Tag))
+			continue
+		}
+
+		field := modelField{name: f.Name, typ: f.Type.String(), tag: f.Tag, prefix: prefix}
+		if t != nil && embedded(f.Tag) {
+			sub := newModel(f.Name)
+			addReflectFields(sub, t, "")
+			field.fields = sub.fields
+		}
+		m.add(field)
+	}
+}
+
+// structType returns the struct type t points to, nil when it is not one.
+func structType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	return t
+}
+
+// embedded reports whether the gorm tag stores the struct in the columns
+// of the table.
+func embedded(tag reflect.StructTag) bool {
+	gormTag, _ := gormTag(tag.Get("gorm"))
+	return gormTag.Embedded || gormTag.EmbeddedPrefix != ""
+}
+
+func embeddedPrefix(tag reflect.StructTag) string {
+	gormTag, _ := gormTag(tag.Get("gorm"))
+	return gormTag.EmbeddedPrefix
+}
+
+func reflectModels(tablesMap *types.TypeMap) models {
+	ms := models{}
+	for name, value := range *tablesMap {
+		ms[name] = reflectModel(name, value)
+	}
+	return ms
+}
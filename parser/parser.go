@@ -1,56 +1,93 @@
 package parser
 
 import (
-	"log"
+	"errors"
+	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/oSethoum/gorming/types"
 	"github.com/oSethoum/gorming/utils"
 )
 
-func Tables(tablesMap *types.TypeMap, typesMode bool) []types.Table {
-	tables := []types.Table{}
+// Tables parses the tables in the order of names, when names is empty the
+// tables are sorted by name so the output stays stable across runs.
+func Tables(tablesMap *types.TypeMap, names []string, typesMode bool) ([]types.Table, error) {
+	return tables(reflectModels(tablesMap), names, typesMode)
+}
 
-	for name, table := range *tablesMap {
-		_, ok := table.Type().MethodByName("Table")
-		method := table.MethodByName("Table")
+func Columns(tablesMap *types.TypeMap, table reflect.Type, typesMode bool) ([]types.Column, error) {
+	return columns(reflectModels(tablesMap), reflectModel(table.Name(), reflect.New(table).Elem()), typesMode)
+}
 
-		newTable := types.Table{
-			Name:    name,
-			Columns: Columns(tablesMap, table.Type(), typesMode),
+func tables(ms models, names []string, typesMode bool) ([]types.Table, error) {
+	tables := []types.Table{}
+	errs := types.Errors{}
+
+	if len(names) == 0 {
+		for name := range ms {
+			names = append(names, name)
 		}
+		sort.Strings(names)
+	}
 
-		if ok {
-			newTable.Table = method.Call(nil)[0].String()
+	for _, name := range names {
+		table, found := ms[name]
+		if !found {
+			continue
 		}
 
-		tables = append(tables, newTable)
+		columns, err := columns(ms, table, typesMode)
+		errs.Add(err)
+
+		tables = append(tables, types.Table{
+			Name:    name,
+			Table:   table.table,
+			Doc:     table.doc,
+			Columns: columns,
+		})
 	}
 
-	return tables
+	return tables, errs.Err()
 }
 
-func Columns(tablesMap *types.TypeMap, table reflect.Type, typesMode bool) []types.Column {
+func columns(ms models, table *model, typesMode bool) ([]types.Column, error) {
+	tableColumns := []types.Column{}
+	errs := types.Errors{}
 
-	fieldsMap := &types.FieldMap{}
-	fields(fieldsMap, table)
-	columns := []types.Column{}
-
-	for name, f := range *fieldsMap {
-		slices := strings.Split(f.Type.String(), ".")
+	for _, f := range table.fields {
+		name := f.name
+		slices := strings.Split(f.typ, ".")
 		rawType := slices[len(slices)-1]
 		rawType = utils.CleanString(rawType, "[]", "*")
 
+		columnTags, tagErrs := tags(f.tag)
+		for _, err := range tagErrs {
+			errs.Add(&types.Error{Table: table.name, Column: name, Err: err})
+		}
+		if f.prefix != "" && len(f.fields) > 0 {
+			columnTags.Gorm.EmbeddedPrefix = f.prefix + columnTags.Gorm.EmbeddedPrefix
+		} else if f.prefix != "" {
+			columnTags.Gorm.Column = f.prefix + utils.Choice(columnTags.Gorm.Column, utils.DBName(name))
+		}
+
 		column := types.Column{
 			Name:    name,
-			Type:    f.Type.String(),
+			Type:    f.typ,
 			RawType: rawType,
-			Tags:    tags(f),
-			Slice:   strings.Contains(f.Type.String(), "[]"),
+			Doc:     f.doc,
+			Tags:    columnTags,
+			Slice:   strings.Contains(f.typ, "[]"),
 		}
 
-		if edgeTable, ok := (*tablesMap)[column.RawType]; ok && !typesMode && !column.Tags.Typescript.SkipEdge {
+		if len(f.fields) > 0 {
+			sub := newModel(table.name)
+			sub.fields = f.fields
+			fields, err := columns(ms, sub, true)
+			column.Fields = fields
+			errs.Add(err)
+		} else if edgeTable, ok := ms[column.RawType]; ok && !typesMode && !column.Tags.Typescript.SkipEdge {
 			edge := &types.Edge{
 				Table:  column.RawType,
 				Unique: !strings.Contains(column.Type, "[]"),
@@ -60,82 +97,98 @@ func Columns(tablesMap *types.TypeMap, table reflect.Type, typesMode bool) []typ
 				edge.Many2Many = column.Tags.Gorm.Many2Many
 				edge.LocalKey = "ID"
 				edge.TableKey = "ID"
-			} else {
-				edgeTableFieldsMap := &types.FieldMap{}
-				fields(edgeTableFieldsMap, edgeTable.Type())
-
-				var keyFound, referenceFound bool
-
-				if edge.Unique {
-					key := utils.Choice(column.Tags.Gorm.ForeignKey, table.Name()+"ID")
-
-					if _, keyFound = (*edgeTableFieldsMap)[key]; keyFound {
-						edge.TableKey = key
+			} else if polymorphic := column.Tags.Gorm.Polymorphic; polymorphic != "" || column.Tags.Gorm.PolymorphicType != "" && column.Tags.Gorm.PolymorphicID != "" {
+				edge.Polymorphic = utils.Choice(polymorphic, strings.TrimSuffix(column.Tags.Gorm.PolymorphicType, "Type"))
+				edge.TableKey = utils.Choice(column.Tags.Gorm.PolymorphicID, polymorphic+"ID")
+				edge.TypeKey = utils.Choice(column.Tags.Gorm.PolymorphicType, polymorphic+"Type")
+				edge.TypeValue = column.Tags.Gorm.PolymorphicValue
+				edge.LocalKey = utils.Choice(column.Tags.Gorm.References, "ID")
+
+				for _, key := range []string{edge.TableKey, edge.TypeKey} {
+					if !edgeTable.has(key) {
+						errs.Add(&types.Error{Table: table.name, Column: column.Name, Err: fmt.Errorf("cannot find polymorphic field %s in %s", key, column.RawType)})
 					}
+				}
+				if !table.has(edge.LocalKey) {
+					errs.Add(&types.Error{Table: table.name, Column: column.Name, Err: errors.New("cannot find reference")})
+				}
+			} else if err := keyEdge(edge, table, edgeTable, column); err != nil {
+				errs.Add(&types.Error{Table: table.name, Column: column.Name, Err: err})
+			}
+			column.Edge = edge
+		}
 
-					if !keyFound {
-						key = utils.Choice(column.Tags.Gorm.ForeignKey, column.Name+"ID")
-					}
-
-					var keyLocal bool
-					if !keyFound {
-						if _, keyFound = (*fieldsMap)[key]; keyFound {
-							edge.LocalKey = key
-							keyLocal = true
-						}
-					}
-
-					reference := utils.Choice(column.Tags.Gorm.References, "ID")
-					if keyLocal {
-						if _, referenceFound = (*edgeTableFieldsMap)[reference]; referenceFound {
-							edge.TableKey = reference
-						}
-
-					} else {
-						if _, referenceFound = (*fieldsMap)[reference]; referenceFound {
-							edge.LocalKey = reference
-						}
-					}
-
-				} else {
-					key := utils.Choice(column.Tags.Gorm.ForeignKey, table.Name()+"ID")
-					reference := utils.Choice(column.Tags.Gorm.References, "ID")
-
-					if _, keyFound = (*edgeTableFieldsMap)[key]; keyFound {
-						edge.TableKey = key
-					}
+		tableColumns = append(tableColumns, column)
+	}
+	return tableColumns, errs.Err()
+}
 
-					if _, referenceFound = (*fieldsMap)[reference]; referenceFound {
-						edge.LocalKey = reference
-					}
+// keyEdge finds the foreign key and the reference of a has one, has many
+// or belongs to edge in the order gorm guesses them: a slice is has many, a
+// struct is has one then belongs to, or belongs to first when it points to
+// its own table so a self reference uses its own <Column>ID.
+func keyEdge(edge *types.Edge, table *model, edgeTable *model, column types.Column) error {
+	type guess struct {
+		owner *model
+		key   string
+		local bool
+	}
 
-				}
+	has := guess{owner: edgeTable, key: utils.Choice(column.Tags.Gorm.ForeignKey, table.name+"ID")}
+	belongs := guess{owner: table, key: utils.Choice(column.Tags.Gorm.ForeignKey, column.Name+"ID"), local: true}
 
-				if !keyFound {
-					log.Fatalf("gorming: cannot find foreignKey for %s.%s", table.Name(), column.Name)
-				}
+	guesses := []guess{has, belongs}
+	switch {
+	case !edge.Unique:
+		guesses = []guess{has}
+	case column.RawType == table.name:
+		guesses = []guess{belongs, has}
+	}
 
-				if !referenceFound {
-					log.Fatalf("gorming: cannot find reference for %s.%s", table.Name(), column.Name)
-				}
+	reference := utils.Choice(column.Tags.Gorm.References, "ID")
+	tried := []string{}
+	for _, g := range guesses {
+		if !g.owner.has(g.key) {
+			tried = append(tried, g.owner.name+"."+g.key)
+			continue
+		}
+		if g.local {
+			edge.LocalKey = g.key
+			if edgeTable.has(reference) {
+				edge.TableKey = reference
+				return nil
+			}
+		} else {
+			edge.TableKey = g.key
+			if table.has(reference) {
+				edge.LocalKey = reference
+				return nil
 			}
-			column.Edge = edge
 		}
-
-		columns = append(columns, column)
+		return errors.New("cannot find reference")
 	}
-	return columns
+	return fmt.Errorf("cannot find foreignKey %s, set gorm foreignKey", strings.Join(tried, " or "))
 }
 
-func Parse(tablesArray []any, typesArray ...any) *types.Schema {
+func Parse(tablesArray []any, typesArray ...any) (*types.Schema, error) {
 	tablesMap := types.TypeMap{}
 	typesMap := types.TypeMap{}
+	tablesNames := []string{}
+	typesNames := []string{}
+	errs := types.Errors{}
 
 	for _, v := range tablesArray {
 		t := reflect.ValueOf(v)
 		for t.Kind() == reflect.Pointer {
 			t = t.Elem()
 		}
+		if t.Kind() != reflect.Struct {
+			errs.Add(fmt.Errorf("table %v is not a struct", v))
+			continue
+		}
+		if _, ok := tablesMap[t.Type().Name()]; !ok {
+			tablesNames = append(tablesNames, t.Type().Name())
+		}
 		tablesMap[t.Type().Name()] = t
 	}
 
@@ -148,11 +201,23 @@ func Parse(tablesArray []any, typesArray ...any) *types.Schema {
 		for t.Kind() == reflect.Pointer {
 			t = t.Elem()
 		}
+		if t.Kind() != reflect.Struct {
+			errs.Add(fmt.Errorf("type %v is not a struct", v))
+			continue
+		}
+		if _, ok := typesMap[t.Type().Name()]; !ok {
+			typesNames = append(typesNames, t.Type().Name())
+		}
 		typesMap[t.Type().Name()] = t
 	}
 
+	tables, err := Tables(&tablesMap, tablesNames, false)
+	errs.Add(err)
+	typesTables, err := Tables(&typesMap, typesNames, true)
+	errs.Add(err)
+
 	return &types.Schema{
-		Tables: Tables(&tablesMap, false),
-		Types:  Tables(&typesMap, true),
-	}
+		Tables: tables,
+		Types:  typesTables,
+	}, errs.Err()
 }
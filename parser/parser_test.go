@@ -0,0 +1,129 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/oSethoum/gorming/types"
+	"github.com/oSethoum/gorming/utils"
+)
+
+// testEdge runs keyEdge on field of table with the models of tables.
+func testEdge(t *testing.T, tables []any, table string, field string) (*types.Edge, error) {
+	t.Helper()
+	typeMap := types.TypeMap{}
+	for _, v := range tables {
+		typeMap[reflect.TypeOf(v).Name()] = reflect.ValueOf(v)
+	}
+	ms := reflectModels(&typeMap)
+
+	f, ok := ms[table].byName[field]
+	if !ok {
+		t.Fatalf("%s has no field %s", table, field)
+	}
+	columnTags, errs := tags(f.tag)
+	if len(errs) > 0 {
+		t.Fatalf("tags of %s.%s: %v", table, field, errs)
+	}
+	column := types.Column{
+		Name:    f.name,
+		Type:    f.typ,
+		RawType: utils.CleanString(f.typ[strings.LastIndex(f.typ, ".")+1:], "[]", "*"),
+		Tags:    columnTags,
+	}
+	edge := &types.Edge{Table: column.RawType, Unique: !strings.Contains(f.typ, "[]")}
+	return edge, keyEdge(edge, ms[table], ms[column.RawType], column)
+}
+
+// edges of a table to itself and two edges to the same table
+type User struct {
+	ID       uint
+	Sent     []Message `gorm:"foreignKey:SenderID"`
+	Received []Message `gorm:"foreignKey:ReceiverID"`
+}
+
+type Message struct {
+	ID         uint
+	SenderID   uint
+	Sender     *User
+	ReceiverID uint
+	Receiver   *User
+}
+
+type Employee struct {
+	ID        uint
+	ManagerID *uint
+	Manager   *Employee
+	Reports   []Employee `gorm:"foreignKey:ManagerID"`
+	MentorID  *uint
+	Mentor    *Employee `gorm:"foreignKey:MentorID"`
+}
+
+func TestKeyEdge(t *testing.T) {
+	employees := []any{Employee{}}
+
+	tests := []struct {
+		name     string
+		tables   []any
+		table    string
+		field    string
+		localKey string
+		tableKey string
+	}{
+		{name: "self reference belongs to", tables: employees, table: "Employee", field: "Manager", localKey: "ManagerID", tableKey: "ID"},
+		{name: "self reference has many", tables: employees, table: "Employee", field: "Reports", localKey: "ID", tableKey: "ManagerID"},
+		{name: "tagged self reference", tables: employees, table: "Employee", field: "Mentor", localKey: "MentorID", tableKey: "ID"},
+		{name: "first edge to the same table", tables: []any{User{}, Message{}}, table: "Message", field: "Sender", localKey: "SenderID", tableKey: "ID"},
+		{name: "second edge to the same table", tables: []any{User{}, Message{}}, table: "Message", field: "Receiver", localKey: "ReceiverID", tableKey: "ID"},
+		{name: "has many by foreignKey", tables: []any{User{}, Message{}}, table: "User", field: "Sent", localKey: "ID", tableKey: "SenderID"},
+		{name: "second has many by foreignKey", tables: []any{User{}, Message{}}, table: "User", field: "Received", localKey: "ID", tableKey: "ReceiverID"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			edge, err := testEdge(t, test.tables, test.table, test.field)
+			if err != nil {
+				t.Fatalf("keyEdge: %v", err)
+			}
+			if edge.LocalKey != test.localKey || edge.TableKey != test.tableKey {
+				t.Fatalf("got local %s table %s, want local %s table %s", edge.LocalKey, edge.TableKey, test.localKey, test.tableKey)
+			}
+		})
+	}
+}
+
+func TestKeyEdgeErrors(t *testing.T) {
+	type Employee struct {
+		ID      uint
+		Reports []Employee
+	}
+	type Profile struct {
+		ID     uint
+		UserID uint
+	}
+	type User struct {
+		ID      uint
+		Profile Profile `gorm:"references:Code"`
+	}
+
+	tests := []struct {
+		name   string
+		tables []any
+		table  string
+		field  string
+		err    string
+	}{
+		{name: "untagged self has many", tables: []any{Employee{}}, table: "Employee", field: "Reports", err: "cannot find foreignKey Employee.EmployeeID, set gorm foreignKey"},
+		{name: "missing reference", tables: []any{User{}, Profile{}}, table: "User", field: "Profile", err: "cannot find reference"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := testEdge(t, test.tables, test.table, test.field)
+			if err == nil || err.Error() != test.err {
+				t.Fatalf("got %v, want %s", err, test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,387 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/token"
+	gotypes "go/types"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/oSethoum/gorming/types"
+	"github.com/oSethoum/gorming/utils"
+	"golang.org/x/tools/go/packages"
+)
+
+// markers in the doc comment of a struct:
+//
+//	//gorming:table  the struct is a table
+//	//gorming:type   the struct is an extra type
+//	//gorming:ignore the struct is skipped even if it embeds Model
+const (
+	markerTable  = "gorming:table"
+	markerType   = "gorming:type"
+	markerIgnore = "gorming:ignore"
+)
+
+// ParseSource loads the packages of entries from dir and builds the schema
+// from their source. When an entry lists no tables, every struct that embeds
+// Model or is marked with //gorming:table is a table.
+func ParseSource(dir string, entries []types.Models) (*types.Schema, error) {
+	patterns := []string{}
+	for _, entry := range entries {
+		if entry.Package == "" {
+			return nil, &types.Error{Err: errors.New("model package is empty")}
+		}
+		patterns = append(patterns, entry.Package)
+	}
+
+	pkgs, err := packages.Load(&packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedTypes | packages.NeedSyntax | packages.NeedTypesInfo | packages.NeedImports | packages.NeedDeps,
+		Dir:  dir,
+	}, patterns...)
+	if err != nil {
+		return nil, &types.Error{Err: err}
+	}
+
+	type selected struct {
+		pkg     *packages.Package
+		spec    structSpec
+		isTable bool
+	}
+
+	if dir, err = filepath.Abs(dir); err != nil {
+		return nil, &types.Error{Err: err}
+	}
+
+	errs := types.Errors{}
+	selection := []selected{}
+	modelFiles := map[*packages.Package]map[string]bool{}
+	for _, entry := range entries {
+		pkg := findPackage(pkgs, dir, entry.Package)
+		if pkg == nil {
+			errs.Add(&types.Error{Err: fmt.Errorf("cannot load package %s", entry.Package)})
+			continue
+		}
+		if modelFiles[pkg] == nil {
+			modelFiles[pkg] = map[string]bool{}
+		}
+		for _, spec := range structSpecs(pkg) {
+			name := spec.spec.Name.Name
+			isTable, isType := false, false
+
+			if len(entry.Tables) > 0 || len(entry.Types) > 0 {
+				isTable = utils.In(name, entry.Tables...)
+				isType = utils.In(name, entry.Types...)
+			} else {
+				switch {
+				case strings.Contains(spec.doc, markerIgnore):
+				case strings.Contains(spec.doc, markerTable):
+					isTable = true
+				case strings.Contains(spec.doc, markerType):
+					isType = true
+				default:
+					isTable = name != "Model" && embedsModel(spec.st)
+				}
+			}
+
+			if !isTable && !isType {
+				continue
+			}
+			selection = append(selection, selected{pkg: pkg, spec: spec, isTable: isTable})
+			modelFiles[pkg][filepath.Clean(pkg.Fset.Position(spec.spec.Pos()).Filename)] = true
+		}
+
+		for _, name := range append(append([]string{}, entry.Tables...), entry.Types...) {
+			if pkg.Types == nil || pkg.Types.Scope().Lookup(name) == nil {
+				errs.Add(&types.Error{Table: name, Err: fmt.Errorf("cannot find %s in %s", name, pkg.PkgPath)})
+			}
+		}
+	}
+
+	// the generated files of the models package name every model, their
+	// errors after a model is removed or renamed must not stop the run
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		files, isModels := modelFiles[pkg]
+		for _, err := range pkg.Errors {
+			if file := errorFile(err); isModels && file != "" && !files[file] {
+				continue
+			}
+			errs.Add(&types.Error{Path: pkg.PkgPath, Err: err})
+		}
+	})
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	tablesMap, typesMap := models{}, models{}
+	tablesNames, typesNames := []string{}, []string{}
+	docs := map[*packages.Package]map[token.Pos]string{}
+	for _, selected := range selection {
+		pkg, name := selected.pkg, selected.spec.spec.Name.Name
+		if docs[pkg] == nil {
+			docs[pkg] = fieldDocs(pkg)
+		}
+
+		m, err := sourceModel(pkg, name, docs[pkg])
+		if err != nil {
+			errs.Add(&types.Error{Table: name, Err: err})
+			continue
+		}
+		m.doc = selected.spec.text
+
+		if selected.isTable {
+			if _, ok := tablesMap[name]; !ok {
+				tablesNames = append(tablesNames, name)
+			}
+			tablesMap[name] = m
+		} else {
+			if _, ok := typesMap[name]; !ok {
+				typesNames = append(typesNames, name)
+			}
+			typesMap[name] = m
+		}
+	}
+
+	tablesTables, err := tables(tablesMap, tablesNames, false)
+	errs.Add(err)
+	typesTables, err := tables(typesMap, typesNames, true)
+	errs.Add(err)
+
+	return &types.Schema{
+		Tables: tablesTables,
+		Types:  typesTables,
+	}, errs.Err()
+}
+
+// errorFile is the file of a package error, empty when it has no position.
+func errorFile(err packages.Error) string {
+	pos := err.Pos
+	for i := 0; i < 2; i++ {
+		colon := strings.LastIndex(pos, ":")
+		if colon < 0 {
+			break
+		}
+		if _, e := strconv.Atoi(pos[colon+1:]); e != nil {
+			break
+		}
+		pos = pos[:colon]
+	}
+	if pos == "" || pos == "-" {
+		return ""
+	}
+	return filepath.Clean(pos)
+}
+
+// findPackage returns the loaded package of pattern, an import path or a
+// path relative to dir.
+func findPackage(pkgs []*packages.Package, dir string, pattern string) *packages.Package {
+	local := build.IsLocalImport(pattern) || filepath.IsAbs(pattern)
+	if local && !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(dir, pattern)
+	}
+	for _, pkg := range pkgs {
+		if local && pkg.Dir != "" && filepath.Clean(pkg.Dir) == filepath.Clean(pattern) {
+			return pkg
+		}
+		if !local && pkg.PkgPath == pattern {
+			return pkg
+		}
+	}
+	return nil
+}
+
+type structSpec struct {
+	spec *ast.TypeSpec
+	st   *ast.StructType
+	doc  string
+	text string
+}
+
+// structSpecs returns the struct declarations of pkg in source order.
+func structSpecs(pkg *packages.Package) []structSpec {
+	specs := []structSpec{}
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, s := range gen.Specs {
+				spec := s.(*ast.TypeSpec)
+				st, ok := spec.Type.(*ast.StructType)
+				if !ok || spec.TypeParams != nil {
+					continue
+				}
+				doc := spec.Doc
+				if doc == nil && len(gen.Specs) == 1 {
+					doc = gen.Doc
+				}
+				specs = append(specs, structSpec{spec: spec, st: st, doc: commentText(doc), text: docText(doc)})
+			}
+		}
+	}
+	return specs
+}
+
+// docText is the doc comment without the markers and directives.
+func docText(doc *ast.CommentGroup) string {
+	if doc == nil {
+		return ""
+	}
+	return strings.TrimSpace(doc.Text())
+}
+
+// fieldDocs returns the doc comment of every struct field of pkg by the
+// position of its name, the line comment is used when there is no doc.
+func fieldDocs(pkg *packages.Package) map[token.Pos]string {
+	docs := map[token.Pos]string{}
+	for _, file := range pkg.Syntax {
+		ast.Inspect(file, func(n ast.Node) bool {
+			st, ok := n.(*ast.StructType)
+			if !ok {
+				return true
+			}
+			for _, field := range st.Fields.List {
+				doc := docText(field.Doc)
+				if doc == "" {
+					doc = docText(field.Comment)
+				}
+				if doc == "" {
+					continue
+				}
+				for _, name := range field.Names {
+					docs[name.Pos()] = doc
+				}
+			}
+			return true
+		})
+	}
+	return docs
+}
+
+// commentText keeps the directives that ast.CommentGroup.Text drops.
+func commentText(doc *ast.CommentGroup) string {
+	if doc == nil {
+		return ""
+	}
+	lines := []string{}
+	for _, c := range doc.List {
+		lines = append(lines, c.Text)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func embedsModel(st *ast.StructType) bool {
+	for _, field := range st.Fields.List {
+		if len(field.Names) > 0 {
+			continue
+		}
+		expr := field.Type
+		if star, ok := expr.(*ast.StarExpr); ok {
+			expr = star.X
+		}
+		switch t := expr.(type) {
+		case *ast.Ident:
+			if t.Name == "Model" {
+				return true
+			}
+		case *ast.SelectorExpr:
+			if t.Sel.Name == "Model" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sourceModel builds the model of the struct name of pkg, the fields of
+// anonymous structs are flattened like reflection does in addReflectFields.
+func sourceModel(pkg *packages.Package, name string, docs map[token.Pos]string) (*model, error) {
+	named, ok := pkg.Types.Scope().Lookup(name).Type().(*gotypes.Named)
+	if !ok {
+		return nil, errors.New("not a named type")
+	}
+	st, ok := named.Underlying().(*gotypes.Struct)
+	if !ok {
+		return nil, errors.New("not a struct")
+	}
+
+	m := newModel(name)
+	addSourceFields(m, st, docs, "")
+
+	// the methods of the value like reflection sees them, promoted ones
+	// included and pointer receivers left out
+	if selection := gotypes.NewMethodSet(named).Lookup(pkg.Types, "Table"); selection != nil {
+		table, err := tableName(pkg, selection.Obj().(*gotypes.Func))
+		if err != nil {
+			return nil, err
+		}
+		m.table = table
+	}
+	return m, nil
+}
+
+func addSourceFields(m *model, st *gotypes.Struct, docs map[token.Pos]string, prefix string) {
+	qualifier := func(p *gotypes.Package) string { return p.Name() }
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		fieldType := f.Type()
+		if pointer, ok := fieldType.(*gotypes.Pointer); ok {
+			fieldType = pointer.Elem()
+		}
+		fieldStruct, isStruct := fieldType.Underlying().(*gotypes.Struct)
+		tag := reflect.StructTag(st.Tag(i))
+		if f.Embedded() && isStruct {
+			addSourceFields(m, fieldStruct, docs, prefix+embeddedPrefix(tag))
+			continue
+		}
+
+		field := modelField{
+			name:   f.Name(),
+			typ:    gotypes.TypeString(f.Type(), qualifier),
+			tag:    tag,
+			doc:    docs[f.Pos()],
+			prefix: prefix,
+		}
+		if isStruct && embedded(field.tag) {
+			sub := newModel(f.Name())
+			addSourceFields(sub, fieldStruct, docs, "")
+			field.fields = sub.fields
+		}
+		m.add(field)
+	}
+}
+
+// tableName reads the name returned by the Table method, it has to return
+// a string literal since the source is not run. A promoted method is read
+// from the package that declares it.
+func tableName(pkg *packages.Package, method *gotypes.Func) (string, error) {
+	packages.Visit([]*packages.Package{pkg}, nil, func(p *packages.Package) {
+		if p.Types == method.Pkg() {
+			pkg = p
+		}
+	})
+
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil || pkg.TypesInfo.Defs[fn.Name] != method {
+				continue
+			}
+			if fn.Body != nil && len(fn.Body.List) == 1 {
+				if ret, ok := fn.Body.List[0].(*ast.ReturnStmt); ok && len(ret.Results) == 1 {
+					if lit, ok := ret.Results[0].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+						return strconv.Unquote(lit.Value)
+					}
+				}
+			}
+			return "", errors.New("the Table method has to return a string literal")
+		}
+	}
+	return "", errors.New("cannot find the source of the Table method")
+}
@@ -0,0 +1,191 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/oSethoum/gorming/types"
+)
+
+// writeModule writes files under a temporary module example.com/app.
+func writeModule(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	files["go.mod"] = "module example.com/app\n\ngo 1.20\n"
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+const sourceModels = `package db
+
+//gorming:table
+type User struct {
+	ID   uint   ` + "`json:\"id\"`" + `
+	Name string ` + "`json:\"name\"`" + `
+}
+`
+
+func TestParseSourceRemovedModel(t *testing.T) {
+	// migration.go was generated when Invoice was still a model
+	dir := writeModule(t, map[string]string{
+		"db/models.go":    sourceModels,
+		"db/migration.go": "package db\n\nvar migrated = []any{&User{}, &Invoice{}}\n",
+	})
+
+	schema, err := ParseSource(dir, []types.Models{{Package: "example.com/app/db"}})
+	if err != nil {
+		t.Fatalf("ParseSource: %v", err)
+	}
+	if len(schema.Tables) != 1 || schema.Tables[0].Name != "User" {
+		t.Fatalf("got tables %+v, want User", schema.Tables)
+	}
+	if len(schema.Tables[0].Columns) != 2 {
+		t.Fatalf("got columns %+v, want ID and Name", schema.Tables[0].Columns)
+	}
+}
+
+func TestParseSourceModelError(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"db/models.go":    strings.Replace(sourceModels, "Name string", "Name Missing", 1),
+		"db/migration.go": "package db\n\nvar migrated = []any{&User{}, &Invoice{}}\n",
+	})
+
+	_, err := ParseSource(dir, []types.Models{{Package: "example.com/app/db"}})
+	if err == nil {
+		t.Fatal("ParseSource: want an error for the models file")
+	}
+	if !strings.Contains(err.Error(), "Missing") || strings.Contains(err.Error(), "Invoice") {
+		t.Fatalf("got %v, want only the error of models.go", err)
+	}
+}
+
+func TestParseSourcePackage(t *testing.T) {
+	dir := writeModule(t, map[string]string{"db/models.go": sourceModels})
+
+	schema, err := ParseSource(dir, []types.Models{{Package: "./db"}})
+	if err != nil {
+		t.Fatalf("ParseSource: %v", err)
+	}
+	if len(schema.Tables) != 1 || schema.Tables[0].Name != "User" {
+		t.Fatalf("got tables %+v, want User", schema.Tables)
+	}
+
+	_, err = ParseSource(dir, []types.Models{{Package: "./models"}})
+	if err == nil || !strings.Contains(err.Error(), "cannot load package ./models") {
+		t.Errorf("ParseSource: got %v, want it cannot load ./models", err)
+	}
+}
+
+func TestParseSourceTableName(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"base/base.go": `package base
+
+type Base struct{ ID uint }
+
+func (Base) Table() string { return "bases" }
+`,
+		"db/models.go": `package db
+
+import "example.com/app/base"
+
+//gorming:table
+type Account struct {
+	base.Base
+	Name string
+}
+
+//gorming:table
+type Session struct {
+	ID uint
+}
+
+func (*Session) Table() string { return "sessions" }
+`,
+	})
+
+	schema, err := ParseSource(dir, []types.Models{{Package: "./db"}})
+	if err != nil {
+		t.Fatalf("ParseSource: %v", err)
+	}
+	// like reflection: promoted methods count, pointer receivers do not
+	want := map[string]string{"Account": "bases", "Session": ""}
+	for _, table := range schema.Tables {
+		if table.Table != want[table.Name] {
+			t.Errorf("%s: got table %q, want %q", table.Name, table.Table, want[table.Name])
+		}
+	}
+}
+
+// anonymous structs with an embeddedPrefix, the same in source below
+type Point struct {
+	Lat float64
+	Lng float64
+}
+
+type Address struct {
+	Street string
+	City   string `gorm:"column:town"`
+	Geo    Point  `gorm:"embedded;embeddedPrefix:geo_"`
+}
+
+type Customer struct {
+	ID       uint
+	*Address `gorm:"embeddedPrefix:addr_"`
+}
+
+const prefixModels = `package db
+
+type Point struct {
+	Lat float64
+	Lng float64
+}
+
+type Address struct {
+	Street string
+	City   string ` + "`gorm:\"column:town\"`" + `
+	Geo    Point  ` + "`gorm:\"embedded;embeddedPrefix:geo_\"`" + `
+}
+
+type Customer struct {
+	ID       uint
+	*Address ` + "`gorm:\"embeddedPrefix:addr_\"`" + `
+}
+`
+
+func TestParseEmbeddedPrefix(t *testing.T) {
+	fromReflect, err := Parse([]any{Customer{}})
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	dir := writeModule(t, map[string]string{"db/models.go": prefixModels})
+	fromSource, err := ParseSource(dir, []types.Models{{Package: "./db", Tables: []string{"Customer"}}})
+	if err != nil {
+		t.Fatalf("ParseSource: %v", err)
+	}
+
+	// the column of a field, or the prefix of an embedded one
+	want := map[string]string{"ID": "", "Street": "addr_street", "City": "addr_town", "Geo": "addr_geo_"}
+	for name, schema := range map[string]*types.Schema{"reflect": fromReflect, "source": fromSource} {
+		got := map[string]string{}
+		for _, column := range schema.Tables[0].Columns {
+			got[column.Name] = column.Tags.Gorm.Column
+			if len(column.Fields) > 0 {
+				got[column.Name] = column.Tags.Gorm.EmbeddedPrefix
+			}
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %v, want %v", name, got, want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"errors"
 	"reflect"
 	"strings"
 
@@ -8,20 +9,16 @@ import (
 	"github.com/oSethoum/gorming/utils"
 )
 
-func fields(fieldsMap *types.FieldMap, s reflect.Type) {
-	for i := 0; i < s.NumField(); i++ {
-		f := s.Field(i)
-		if f.Type.Kind() == reflect.Struct && f.Anonymous {
-			fields(fieldsMap, f.Type)
-		} else {
-			(*fieldsMap)[f.Name] = f
-		}
-	}
+// Tags parses the tags of a struct field the way the schema does.
+func Tags(tag reflect.StructTag) (types.Tags, error) {
+	tags, errs := tags(tag)
+	return tags, errors.Join(errs...)
 }
 
-func tags(f reflect.StructField) types.Tags {
-	tags := types.Tags{}
-	jsonTagString := utils.CleanString(f.Tag.Get("json"), " ")
+func tags(tag reflect.StructTag) (types.Tags, []error) {
+	tags := types.Tags{Raw: string(tag)}
+	errs := []error{}
+	jsonTagString := utils.CleanString(tag.Get("json"), " ")
 
 	if len(jsonTagString) > 0 {
 		jsonTag := types.JsonTag{
@@ -32,70 +29,13 @@ func tags(f reflect.StructField) types.Tags {
 		tags.Json = jsonTag
 	}
 
-	gormTagString := utils.CleanString(f.Tag.Get("gorm"), " ")
-	if len(gormTagString) > 0 {
-		gormTag := types.GormTag{
-			PrimaryKey: strings.Contains(gormTagString, "primarykey"),
-		}
-		gormTagStringArray := strings.Split(gormTagString, ";")
-		for _, value := range gormTagStringArray {
-
-			if value == "unique" {
-				gormTag.Unique = true
-			}
-
-			if strings.Contains(value, "OnUpdate:CASCADE") {
-				gormTag.OnUpdate = "CASCADE"
-			}
-
-			if strings.Contains(value, "OnUpdate:SET NLL") {
-				gormTag.OnUpdate = "SET NLL"
-			}
-
-			if strings.Contains(value, "OnUpdate:RESTRICT") {
-				gormTag.OnUpdate = "RESTRICT"
-			}
-
-			if strings.Contains(value, "OnUpdate:NO ACTION") {
-				gormTag.OnUpdate = "NO ACTION"
-			}
-
-			if strings.Contains(value, "OnDelete:CASCADE") {
-				gormTag.OnDelete = "CASCADE"
-			}
-
-			if strings.Contains(value, "OnDelete:SET NLL") {
-				gormTag.OnDelete = "SET NLL"
-			}
-
-			if strings.Contains(value, "OnDelete:RESTRICT") {
-				gormTag.OnDelete = "RESTRICT"
-			}
-
-			if strings.Contains(value, "OnDelete:NO ACTION") {
-				gormTag.OnDelete = "NO ACTION"
-			}
-
-			if strings.HasPrefix(value, "foreignKey:") {
-				gormTag.ForeignKey = utils.CleanString(value, "foreignKey:")
-			}
-			if strings.HasPrefix(value, "references:") {
-				gormTag.References = utils.CleanString(value, "references:")
-			}
-			if strings.HasPrefix(value, "column:") {
-				gormTag.Column = utils.CleanString(value, "column:")
-			}
-			if strings.HasPrefix(value, "default:") {
-				gormTag.Default = utils.CleanString(value, "default:")
-			}
-			if strings.HasPrefix(value, "many2many:") {
-				gormTag.Many2Many = utils.CleanString(value, "many2many:")
-			}
-		}
+	if gormTagString := strings.TrimSpace(tag.Get("gorm")); len(gormTagString) > 0 {
+		gormTag, gormErrs := gormTag(gormTagString)
 		tags.Gorm = gormTag
+		errs = append(errs, gormErrs...)
 	}
 
-	swaggerTagString := strings.TrimSpace(f.Tag.Get("swagger"))
+	swaggerTagString := strings.TrimSpace(tag.Get("swagger"))
 	if len(swaggerTagString) > 0 {
 		swaggerTag := types.SwaggerTag{}
 		for _, value := range strings.Split(swaggerTagString, ";") {
@@ -109,7 +49,7 @@ func tags(f reflect.StructField) types.Tags {
 		tags.Swagger = swaggerTag
 	}
 
-	typescriptTagString := strings.TrimSpace(f.Tag.Get("typescript"))
+	typescriptTagString := strings.TrimSpace(tag.Get("typescript"))
 	if len(typescriptTagString) > 0 {
 		typescriptTag := types.TypescriptTag{}
 		for _, value := range strings.Split(typescriptTagString, ";") {
@@ -128,11 +68,15 @@ func tags(f reflect.StructField) types.Tags {
 		tags.Typescript = typescriptTag
 	}
 
-	validatorTagString := strings.TrimSpace(f.Tag.Get("validate"))
+	validatorTagString := strings.TrimSpace(tag.Get("validate"))
 	if len(validatorTagString) > 0 {
 
 		validatorTag := []types.ValidatorTag{}
 		for _, value := range strings.Split(validatorTagString, ";") {
+			value = strings.TrimSpace(value)
+			if value == "" {
+				continue
+			}
 			if strings.HasPrefix(value, "notEmpty") {
 				validatorTag = append(validatorTag, types.ValidatorTag{
 					Rule: "notEmpty",
@@ -249,5 +193,5 @@ func tags(f reflect.StructField) types.Tags {
 		tags.Validator = validatorTag
 	}
 
-	return tags
+	return tags, errs
 }
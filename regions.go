@@ -0,0 +1,145 @@
+package gorming
+
+import (
+	"errors"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/oSethoum/gorming/types"
+	"github.com/oSethoum/gorming/utils"
+)
+
+// user regions are kept when a file is generated again:
+//
+//	// gorming:begin custom
+//	... user code ...
+//	// gorming:end
+var regionBegin = regexp.MustCompile(`gorming:begin\s+([\w.-]+)`)
+
+const regionEnd = "gorming:end"
+
+// regions returns the body of every user region in content by name.
+func regions(content string) map[string]string {
+	out := map[string]string{}
+	name, body := "", []string{}
+	inside := false
+
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if inside {
+			if strings.Contains(line, regionEnd) {
+				out[name] = strings.Join(body, "")
+				inside = false
+				continue
+			}
+			body = append(body, line)
+			continue
+		}
+
+		if match := regionBegin.FindStringSubmatch(line); match != nil {
+			name, body, inside = match[1], []string{}, true
+		}
+	}
+	return out
+}
+
+// mergeRegions replaces the body of each region in generated with the one
+// found in existing and returns the existing regions that were dropped.
+func mergeRegions(generated string, existing string) (string, []string) {
+	kept := regions(existing)
+	builder := new(strings.Builder)
+	name := ""
+	inside := false
+
+	for _, line := range strings.SplitAfter(generated, "\n") {
+		if inside {
+			if strings.Contains(line, regionEnd) {
+				inside = false
+				builder.WriteString(line)
+			} else if _, ok := kept[name]; !ok {
+				builder.WriteString(line)
+			}
+			continue
+		}
+
+		builder.WriteString(line)
+		if match := regionBegin.FindStringSubmatch(line); match != nil {
+			name, inside = match[1], true
+			if body, ok := kept[name]; ok {
+				builder.WriteString(body)
+				delete(kept, name)
+			}
+		}
+	}
+
+	dropped := []string{}
+	for name, body := range kept {
+		if strings.TrimSpace(body) != "" {
+			dropped = append(dropped, name)
+		}
+	}
+	sort.Strings(dropped)
+	return builder.String(), dropped
+}
+
+// stripRegions empties every region so hand-written code inside them does
+// not count as an edit of the generated file.
+func stripRegions(content string) string {
+	builder := new(strings.Builder)
+	inside := false
+
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if inside {
+			if !strings.Contains(line, regionEnd) {
+				continue
+			}
+			inside = false
+		}
+		builder.WriteString(line)
+		if regionBegin.MatchString(line) {
+			inside = true
+		}
+	}
+	return builder.String()
+}
+
+// preserveUserCode merges the user regions of the files already on disk
+// into the outputs, files listed in Config.Once keep their current content.
+func preserveUserCode(config types.Config, outputs []output) error {
+	errs := types.Errors{}
+	for i, o := range outputs {
+		existing, err := os.ReadFile(o.path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			errs.Add(&types.Error{Path: o.path, Err: err})
+			continue
+		}
+
+		if o.file != 0 && utils.In(o.file, config.Once...) {
+			outputs[i].content = existing
+			continue
+		}
+
+		merged, dropped := mergeRegions(string(o.content), string(existing))
+		for _, name := range dropped {
+			log.Printf("gorming: region %s of %s no longer exists in the template, its code is lost\n", name, relativePath(config.Paths.BasePath, o.path))
+		}
+
+		content := []byte(merged)
+		if strings.HasSuffix(o.path, ".go") && merged != string(o.content) {
+			content, err = format.Source(content)
+			if err != nil {
+				errs.Add(&types.Error{Path: o.path, Err: fmt.Errorf("user region: %w", err)})
+				continue
+			}
+		}
+		outputs[i].content = content
+	}
+	return errs.Err()
+}
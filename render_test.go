@@ -0,0 +1,90 @@
+package gorming
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/oSethoum/gorming/types"
+)
+
+type renderUser struct {
+	ID   uint   `json:"id" gorm:"primarykey"`
+	Name string `json:"name"`
+}
+
+func TestRenderDebugSchema(t *testing.T) {
+	files, err := NewGenerator(types.Config{Package: "example.com/app", Debug: true, Paths: types.Paths{BasePath: t.TempDir()}}).Render([]any{renderUser{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := files["schema.json"]; !ok {
+		t.Error("schema.json is not rendered at the base path")
+	}
+	for name := range files {
+		if strings.HasPrefix(name, "../") {
+			t.Errorf("%s is outside the base path", name)
+		}
+	}
+}
+
+type writeRecorder struct {
+	changed map[string]bool
+}
+
+func (p *writeRecorder) Name() string { return "record" }
+
+func (p *writeRecorder) AfterWrite(path string, content []byte, changed bool) error {
+	p.changed[filepath.Base(path)] = changed
+	return nil
+}
+
+func TestWriteOutputsAfterWrite(t *testing.T) {
+	dir := t.TempDir()
+	same := filepath.Join(dir, "same.go")
+	if err := os.WriteFile(same, []byte("package db\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := &writeRecorder{changed: map[string]bool{}}
+	err := writeOutputs([]types.Plugin{recorder}, []output{
+		{path: same, content: []byte("package db\n")},
+		{path: filepath.Join(dir, "new.go"), content: []byte("package db\n")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]bool{"same.go": false, "new.go": true}
+	if !reflect.DeepEqual(recorder.changed, want) {
+		t.Fatalf("got %v, want %v", recorder.changed, want)
+	}
+}
+
+type docPlugin struct{}
+
+func (docPlugin) Name() string { return "doc" }
+
+func (docPlugin) BeforeRender(templateName string, path string, data *types.TemplateData) error {
+	data.Schema.Tables[0].Doc = "renderUser is written by a plugin."
+	return nil
+}
+
+// TestRenderBeforeRenderSchema changes the schema from BeforeRender, run
+// with -race it fails if a template reads the schema at the same time.
+func TestRenderBeforeRenderSchema(t *testing.T) {
+	// the renders only overlap with more than one proc
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(8))
+	for i := 0; i < 5; i++ {
+		files, err := NewGenerator(types.Config{Package: "example.com/app", Plugins: []types.Plugin{docPlugin{}}, Paths: types.Paths{BasePath: t.TempDir()}}).Render([]any{renderUser{}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(files["client/typescript/gorming/types.ts"]), "renderUser is written by a plugin.") {
+			t.Fatal("types.ts does not have the doc of the plugin")
+		}
+	}
+}
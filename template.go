@@ -3,59 +3,444 @@ package gorming
 import (
 	"bytes"
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path"
 	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
 	"text/template"
 
 	"github.com/oSethoum/gorming/types"
 	"github.com/oSethoum/gorming/utils"
 )
 
-func parseTemplate(templateName string, data types.TemplateData) *bytes.Buffer {
+// readTemplate prefers the user override at the same path and falls back
+// to the embedded template.
+func readTemplate(overrides fs.FS, templateName string) ([]byte, error) {
+	if overrides != nil {
+		file, err := fs.ReadFile(overrides, templateName+".tmpl")
+		if err == nil {
+			return file, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return templates.ReadFile("templates/" + templateName + ".tmpl")
+}
+
+// parsePartials adds every other override template to engine so their
+// define blocks can be used from any template.
+func parsePartials(engine *template.Template, overrides fs.FS, templateName string) error {
+	if overrides == nil {
+		return nil
+	}
+	return fs.WalkDir(overrides, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(name, ".tmpl") || name == templateName+".tmpl" {
+			return nil
+		}
+		file, err := fs.ReadFile(overrides, name)
+		if err != nil {
+			return err
+		}
+		_, err = engine.New(strings.TrimSuffix(name, ".tmpl")).Parse(string(file))
+		return err
+	})
+}
+
+// renderer renders the targets of one run. Every template is parsed once
+// and cloned with the functions of each data. The paths and the
+// BeforeRender hooks of every file run first, in order, then the templates
+// execute concurrently, the other plugin hooks are never called at the same
+// time.
+type renderer struct {
+	overrides fs.FS
+	plugins   sync.Mutex
+	mu        sync.Mutex
+	templates map[string]*parsedTemplate
+	// placeholder has the names of the functions to parse the templates,
+	// they are replaced with the ones of the data before every execution
+	placeholder template.FuncMap
+}
+
+type parsedTemplate struct {
+	once   sync.Once
+	engine *template.Template
+	err    error
+}
+
+func newRenderer(overrides fs.FS) *renderer {
+	return &renderer{overrides: overrides, templates: map[string]*parsedTemplate{}}
+}
+
+// funcs returns the built-in and plugin functions bound to data.
+func (r *renderer) funcs(data *types.TemplateData) template.FuncMap {
+	r.plugins.Lock()
+	defer r.plugins.Unlock()
+	return mergeFuncs(templateFunctions(data), pluginFunctions(data))
+}
+
+// parse returns the template with the partials of the overrides, it is
+// shared by every execution and only used through clones.
+func (r *renderer) parse(templateName string) (*template.Template, error) {
+	r.mu.Lock()
+	parsed, ok := r.templates[templateName]
+	if !ok {
+		parsed = &parsedTemplate{}
+		r.templates[templateName] = parsed
+	}
+	r.mu.Unlock()
 
-	file, err := templates.ReadFile("templates/" + templateName + ".tmpl")
+	parsed.once.Do(func() {
+		file, err := readTemplate(r.overrides, templateName)
+		if err != nil {
+			parsed.err = err
+			return
+		}
+		engine, err := template.New(templateName).Funcs(r.placeholder).Parse(string(file))
+		if err != nil {
+			parsed.err = err
+			return
+		}
+		parsed.err = parsePartials(engine, r.overrides, templateName)
+		parsed.engine = engine
+	})
+	return parsed.engine, parsed.err
+}
+
+func (r *renderer) execute(templateName string, data types.TemplateData, funcs template.FuncMap) (*bytes.Buffer, error) {
+	engine, err := r.parse(templateName)
+	if err != nil {
+		return nil, &types.Error{Template: templateName, Err: err}
+	}
+	engine, err = engine.Clone()
 	if err != nil {
-		log.Fatalf("gorming: error parsing templates %s, %s \n", templateName, err.Error())
+		return nil, &types.Error{Template: templateName, Err: err}
 	}
 	buffer := new(bytes.Buffer)
-	engine, err := template.New(templateName).Funcs(templateFunctions(&data)).Parse(string(file))
+	if err := engine.Funcs(funcs).Execute(buffer, data); err != nil {
+		return nil, &types.Error{Template: templateName, Err: err}
+	}
+	return buffer, nil
+}
+
+type output struct {
+	path    string
+	content []byte
+	file    types.File
+}
+
+type renderJob struct {
+	target types.Target
+	data   types.TemplateData
+	path   string
+	funcs  template.FuncMap
+}
+
+// render renders every target concurrently, the outputs and the errors keep
+// the order of the targets and of the tables.
+func (r *renderer) render(targets []types.Target, data types.TemplateData) ([]output, error) {
+	r.placeholder = r.funcs(&types.TemplateData{Schema: &types.Schema{}, Config: data.Config})
+
+	jobs := []renderJob{}
+	for _, target := range targets {
+		if !utils.In(target.File, data.Config.Files...) == bool(data.Config.FilesAction) {
+			continue
+		}
+		if !target.PerTable {
+			jobs = append(jobs, renderJob{target: target, data: data})
+			continue
+		}
+		for i := range data.Schema.Tables {
+			tableData := data
+			tableData.Table = &data.Schema.Tables[i]
+			jobs = append(jobs, renderJob{target: target, data: tableData})
+		}
+	}
+
+	outputs := make([]output, len(jobs))
+	failures := make([]error, len(jobs))
+
+	// the hooks can change the schema, they are done before any template
+	// reads it
+	for i := range jobs {
+		failures[i] = r.prepare(&jobs[i])
+	}
+
+	limit := make(chan struct{}, runtime.GOMAXPROCS(0))
+	wg := sync.WaitGroup{}
+	for i, job := range jobs {
+		if failures[i] != nil {
+			continue
+		}
+		wg.Add(1)
+		limit <- struct{}{}
+		go func() {
+			defer wg.Done()
+			outputs[i], failures[i] = r.renderTemplate(job)
+			<-limit
+		}()
+	}
+	wg.Wait()
+
+	errs := types.Errors{}
+	rendered := []output{}
+	for i := range jobs {
+		if failures[i] != nil {
+			errs.Add(failures[i])
+			continue
+		}
+		rendered = append(rendered, outputs[i])
+	}
+	return rendered, errs.Err()
+}
+
+// prepare renders the path of the job and runs the BeforeRender hooks, the
+// functions are bound to the data the hooks leave.
+func (r *renderer) prepare(job *renderJob) error {
+	templateName := job.target.Template
+	filePath, err := r.renderPath(job.target.Path, job.data)
 	if err != nil {
-		log.Fatalf("gorming: error executing template %s, %s \n", templateName, err.Error())
+		return &types.Error{Table: dataTable(job.data), Template: templateName, Path: job.target.Path, Err: err}
+	}
+	job.path = filePath
+
+	if err := r.beforeRender(templateName, filePath, &job.data); err != nil {
+		return err
+	}
+	job.funcs = r.funcs(&job.data)
+	return nil
+}
+
+func (r *renderer) renderPath(outPath string, data types.TemplateData) (string, error) {
+	if !strings.Contains(outPath, "{{") {
+		return outPath, nil
 	}
-	err = engine.Execute(buffer, data)
+	buffer := new(bytes.Buffer)
+	engine, err := template.New(outPath).Funcs(r.funcs(&data)).Parse(outPath)
 	if err != nil {
-		log.Fatalf("gorming: error executing template %s, %s \n", templateName, err.Error())
+		return "", err
+	}
+	if err := engine.Execute(buffer, data); err != nil {
+		return "", err
 	}
-	return buffer
+	return buffer.String(), nil
 }
 
-func writeTemplate(templateName string, filepath string, data types.TemplateData, file types.File) {
-	if !utils.In(file, data.Config.Files...) == bool(data.Config.FilesAction) {
+func (r *renderer) renderTemplate(job renderJob) (output, error) {
+	templateName, filePath, data := job.target.Template, job.path, job.data
+	table := dataTable(data)
+
+	buffer, err := r.execute(templateName, data, job.funcs)
+	if err != nil {
+		if e, ok := err.(*types.Error); ok {
+			e.Table = table
+			e.Path = filePath
+		}
+		return output{}, err
+	}
+
+	content := buffer.Bytes()
+	if strings.HasSuffix(filePath, ".go") {
+		content, err = formatGo(filePath, content)
+		if err != nil {
+			if table == "" {
+				table = r.invalidTable(templateName, filePath, data)
+			}
+			return output{}, &types.Error{Table: table, Template: templateName, Path: filePath, Err: err}
+		}
+	}
+
+	content, err = r.afterRender(templateName, filePath, table, content, data.Config.Plugins)
+	if err != nil {
+		return output{}, err
+	}
+
+	return output{
+		path:    path.Join(data.Config.Paths.BasePath, filePath),
+		content: content,
+		file:    job.target.File,
+	}, nil
+}
+
+// dataTable is the name of the table a per table file renders.
+func dataTable(data types.TemplateData) string {
+	if data.Table == nil {
+		return ""
+	}
+	return data.Table.Name
+}
+
+func (r *renderer) beforeRender(templateName string, filePath string, data *types.TemplateData) error {
+	for _, plugin := range data.Config.Plugins {
+		if p, ok := plugin.(types.TemplatePlugin); ok {
+			if err := p.BeforeRender(templateName, filePath, data); err != nil {
+				return pluginError(plugin, &types.Error{Table: dataTable(*data), Template: templateName, Path: filePath, Err: err})
+			}
+		}
+	}
+	return nil
+}
+
+func (r *renderer) afterRender(templateName string, filePath string, tableName string, content []byte, plugins []types.Plugin) ([]byte, error) {
+	r.plugins.Lock()
+	defer r.plugins.Unlock()
+	for _, plugin := range plugins {
+		if p, ok := plugin.(types.OutputPlugin); ok {
+			var err error
+			content, err = p.AfterRender(filePath, content)
+			if err != nil {
+				return nil, pluginError(plugin, &types.Error{Table: tableName, Template: templateName, Path: filePath, Err: err})
+			}
+		}
+	}
+	return content, nil
+}
+
+// mergeFuncs merges the function maps, the later ones win.
+func mergeFuncs(maps ...template.FuncMap) template.FuncMap {
+	funcs := template.FuncMap{}
+	for _, m := range maps {
+		for name, fn := range m {
+			funcs[name] = fn
+		}
+	}
+	return funcs
+}
+
+func pluginFunctions(data *types.TemplateData) template.FuncMap {
+	funcs := template.FuncMap{}
+	for _, plugin := range data.Config.Plugins {
+		if p, ok := plugin.(types.FuncsPlugin); ok {
+			for name, fn := range p.Funcs(data) {
+				funcs[name] = fn
+			}
+		}
+	}
+	return funcs
+}
+
+func planOutputs(config types.Config, outputs []output) ([]types.Change, error) {
+	changes := []types.Change{}
+	errs := types.Errors{}
+
+	for _, o := range outputs {
+		name := relativePath(config.Paths.BasePath, o.path)
+
+		current, err := os.ReadFile(o.path)
+		if err != nil && !os.IsNotExist(err) {
+			errs.Add(&types.Error{Path: o.path, Err: err})
+			continue
+		}
+
+		if os.IsNotExist(err) {
+			changes = append(changes, types.Change{
+				Path:   name,
+				Action: types.ChangeCreate,
+				Diff:   utils.UnifiedDiff("/dev/null", "b/"+name, nil, o.content),
+			})
+			continue
+		}
+
+		if !bytes.Equal(current, o.content) {
+			changes = append(changes, types.Change{
+				Path:   name,
+				Action: types.ChangeUpdate,
+				Diff:   utils.UnifiedDiff("a/"+name, "b/"+name, current, o.content),
+			})
+		}
+	}
+
+	deletes, err := planDeletes(config, outputs)
+	errs.Add(err)
+	changes = append(changes, deletes...)
+
+	return changes, errs.Err()
+}
+
+func printChanges(w io.Writer, changes []types.Change, diff bool) {
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "gorming: no changes")
 		return
 	}
-	buffer := parseTemplate(templateName, data)
-	writeFile(path.Join(data.Config.Paths.BasePath, filepath), buffer.Bytes())
+
+	for _, change := range changes {
+		if diff {
+			fmt.Fprint(w, change.Diff)
+		} else {
+			fmt.Fprintf(w, "%-7s %s\n", change.Action, change.Path)
+		}
+	}
 }
 
-func writeFile(outPath string, data []byte) {
+func checkOutputs(config types.Config, outputs []output) error {
+	changes, err := planOutputs(config, outputs)
+	if err != nil {
+		return err
+	}
+
+	errs := types.Errors{}
+	for _, change := range changes {
+		errs.Add(&types.Error{Path: change.Path, Err: fmt.Errorf("generated file is stale (%s)", change.Action)})
+	}
+	return errs.Err()
+}
+
+func writeOutputs(plugins []types.Plugin, outputs []output) error {
+	errs := types.Errors{}
+	for _, o := range outputs {
+		current, err := os.ReadFile(o.path)
+		changed := err != nil || !bytes.Equal(current, o.content)
+		if changed {
+			if err := writeFile(o.path, o.content); err != nil {
+				errs.Add(err)
+				continue
+			}
+		}
+		for _, plugin := range plugins {
+			if p, ok := plugin.(types.WritePlugin); ok {
+				if err := p.AfterWrite(o.path, o.content, changed); err != nil {
+					errs.Add(pluginError(plugin, &types.Error{Path: o.path, Err: err}))
+				}
+			}
+		}
+	}
+	return errs.Err()
+}
+
+func writeFile(outPath string, data []byte) error {
 	err := os.MkdirAll(filepath.Dir(outPath), 0777)
 
 	if err != nil {
-		log.Fatalf("gorming: %s \n", err.Error())
+		return &types.Error{Path: outPath, Err: err}
 	}
 
-	err = os.WriteFile(outPath, data, 07777)
+	err = os.WriteFile(outPath, data, 0666)
 	if err != nil {
-		log.Fatalf("gorming: %s \n", err.Error())
+		return &types.Error{Path: outPath, Err: err}
 	}
+	return nil
 }
 
-func writeJSON(filename string, data any) {
+func renderJSON(outputs *[]output, filename string, data any) error {
 	buffer := new(bytes.Buffer)
 	encoder := json.NewEncoder(buffer)
 	encoder.SetEscapeHTML(false)
-	encoder.Encode(data)
-	writeFile(filename, buffer.Bytes())
+	if err := encoder.Encode(data); err != nil {
+		return &types.Error{Path: filename, Err: err}
+	}
+	outPath, err := filepath.Abs(filename)
+	if err != nil {
+		return &types.Error{Path: filename, Err: err}
+	}
+	*outputs = append(*outputs, output{path: outPath, content: buffer.Bytes()})
+	return nil
 }
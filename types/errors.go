@@ -0,0 +1,81 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Error is a single generation problem with the place it happened.
+type Error struct {
+	Group    string `json:"group,omitempty"`
+	Table    string `json:"table,omitempty"`
+	Column   string `json:"column,omitempty"`
+	Template string `json:"template,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Err      error  `json:"-"`
+}
+
+func (e *Error) Error() string {
+	where := []string{}
+	if e.Group != "" {
+		where = append(where, "group "+e.Group)
+	}
+	if e.Table != "" {
+		if e.Column != "" {
+			where = append(where, e.Table+"."+e.Column)
+		} else {
+			where = append(where, e.Table)
+		}
+	}
+	if e.Template != "" {
+		where = append(where, "template "+e.Template)
+	}
+	if e.Path != "" {
+		where = append(where, e.Path)
+	}
+
+	if len(where) == 0 {
+		return fmt.Sprintf("gorming: %v", e.Err)
+	}
+	return fmt.Sprintf("gorming: %s: %v", strings.Join(where, ", "), e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Errors collects every problem found during a run.
+type Errors []*Error
+
+func (e Errors) Error() string {
+	messages := []string{}
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Add appends err to the list, nil errors are ignored and nested
+// Errors are flattened.
+func (e *Errors) Add(err error) {
+	switch v := err.(type) {
+	case nil:
+		return
+	case *Error:
+		if v != nil {
+			*e = append(*e, v)
+		}
+	case Errors:
+		*e = append(*e, v...)
+	default:
+		*e = append(*e, &Error{Err: err})
+	}
+}
+
+// Err returns nil when no error was collected.
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
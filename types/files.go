@@ -0,0 +1,120 @@
+package types
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Files are generated files by their slash separated path relative to
+// Paths.BasePath.
+type Files map[string][]byte
+
+// FS returns the files as a read-only file system, the folders are
+// derived from the paths.
+func (f Files) FS() fs.FS {
+	return filesFS(f)
+}
+
+type filesFS Files
+
+func (f filesFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if content, ok := f[name]; ok {
+		return &openFile{info: fileInfo{name: path.Base(name), size: int64(len(content))}, Reader: bytes.NewReader(content)}, nil
+	}
+	entries := f.entries(name)
+	if len(entries) == 0 && name != "." {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return &openDir{info: fileInfo{name: path.Base(name), dir: true}, entries: entries}, nil
+}
+
+// entries lists the files and folders right under dir sorted by name.
+func (f filesFS) entries(dir string) []fs.DirEntry {
+	prefix := dir + "/"
+	if dir == "." {
+		prefix = ""
+	}
+
+	seen := map[string]bool{}
+	entries := []fs.DirEntry{}
+	for name, content := range f {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		child, _, isDir := strings.Cut(name[len(prefix):], "/")
+		if seen[child] {
+			continue
+		}
+		seen[child] = true
+		info := fileInfo{name: child, dir: isDir}
+		if !isDir {
+			info.size = int64(len(content))
+		}
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries
+}
+
+type fileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (i fileInfo) Name() string       { return i.name }
+func (i fileInfo) Size() int64        { return i.size }
+func (i fileInfo) ModTime() time.Time { return time.Time{} }
+func (i fileInfo) IsDir() bool        { return i.dir }
+func (i fileInfo) Sys() any           { return nil }
+
+func (i fileInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0555
+	}
+	return 0444
+}
+
+type openFile struct {
+	*bytes.Reader
+	info fileInfo
+}
+
+func (f *openFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *openFile) Close() error               { return nil }
+
+type openDir struct {
+	info    fileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *openDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *openDir) Close() error               { return nil }
+
+func (d *openDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: errors.New("is a directory")}
+}
+
+func (d *openDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(rest))
+	d.offset += n
+	return rest[:n], nil
+}
@@ -0,0 +1,28 @@
+package types
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFilesFS(t *testing.T) {
+	files := Files{
+		"db/db.go":                   []byte("package db\n"),
+		"db/query.go":                []byte("package db\n\nfunc Query() {}\n"),
+		"client/typescript/api.ts":   []byte("export {};\n"),
+		"client/typescript/types.ts": []byte(""),
+		"schema.json":                []byte("{}\n"),
+	}
+	if err := fstest.TestFS(files.FS(), "db/db.go", "db/query.go", "client/typescript/api.ts", "client/typescript/types.ts", "schema.json"); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := fs.ReadFile(files.FS(), "db/query.go")
+	if err != nil || string(content) != "package db\n\nfunc Query() {}\n" {
+		t.Fatalf("got %q, %v", content, err)
+	}
+	if _, err := files.FS().Open("../db/db.go"); err == nil {
+		t.Fatal("opened a path outside the files")
+	}
+}
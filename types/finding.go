@@ -0,0 +1,29 @@
+package types
+
+import "fmt"
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Finding is a problem of the models reported by the doctor.
+type Finding struct {
+	Severity Severity `json:"severity"`
+	Table    string   `json:"table,omitempty"`
+	Column   string   `json:"column,omitempty"`
+	Message  string   `json:"message"`
+}
+
+func (f Finding) String() string {
+	where := f.Table
+	if f.Column != "" {
+		where += "." + f.Column
+	}
+	if where == "" {
+		return fmt.Sprintf("%-7s %s", f.Severity, f.Message)
+	}
+	return fmt.Sprintf("%-7s %s: %s", f.Severity, where, f.Message)
+}
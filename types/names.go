@@ -0,0 +1,90 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+var caseNames = map[string]Case{
+	"camel":  Camel,
+	"pascal": Pascal,
+	"snake":  Snake,
+}
+
+var serverNames = map[string]Server{
+	"fiber": Fiber,
+	"wails": Wails,
+}
+
+// FileNames are the names of the generated files in the config file, the
+// case and server fields take their names as well.
+var FileNames = map[string]File{
+	"db":          FileDB,
+	"routes":      FileRoutes,
+	"handler":     FileHandler,
+	"hooks":       FileHooks,
+	"migration":   FileMigration,
+	"query":       FileQuery,
+	"error":       FileError,
+	"response":    FileResponse,
+	"middlewares": FileMiddlewares,
+	"ws":          FileWs,
+	"utils":       FileUtils,
+	"request":     FileRequest,
+	"api":         FileApi,
+	"resource":    FileResource,
+	"schema":      FileSchema,
+	"ts_api":      FileTsApi,
+	"ts_types":    FileTsTypes,
+	"ts_event":    FileTsEvent,
+}
+
+// lookupName resolves a json string through names, ok is false when data
+// is not a string and has to be decoded as a number.
+func lookupName[T any](data []byte, names map[string]T, kind string) (value T, ok bool, err error) {
+	var name string
+	if json.Unmarshal(data, &name) != nil {
+		return value, false, nil
+	}
+	value, ok = names[name]
+	if !ok {
+		return value, true, fmt.Errorf("unknown %s %q", kind, name)
+	}
+	return value, true, nil
+}
+
+func (c *Case) UnmarshalJSON(data []byte) error {
+	value, ok, err := lookupName(data, caseNames, "case")
+	if ok || err != nil {
+		*c = value
+		return err
+	}
+	var number float64
+	err = json.Unmarshal(data, &number)
+	*c = Case(number)
+	return err
+}
+
+func (s *Server) UnmarshalJSON(data []byte) error {
+	value, ok, err := lookupName(data, serverNames, "server")
+	if ok || err != nil {
+		*s = value
+		return err
+	}
+	var number int
+	err = json.Unmarshal(data, &number)
+	*s = Server(number)
+	return err
+}
+
+func (f *File) UnmarshalJSON(data []byte) error {
+	value, ok, err := lookupName(data, FileNames, "file")
+	if ok || err != nil {
+		*f = value
+		return err
+	}
+	var number uint
+	err = json.Unmarshal(data, &number)
+	*f = File(number)
+	return err
+}
@@ -0,0 +1,47 @@
+package types
+
+import "text/template"
+
+// Plugin extends the generator, it implements any of the hook interfaces
+// below and is passed to gorming.New or gorming.NewGenerator. Templates
+// render concurrently but the hooks of a run are never called at the same
+// time.
+type Plugin interface {
+	Name() string
+}
+
+// SchemaPlugin runs after the parser, it can add synthetic tables or
+// columns and attach Meta to them.
+type SchemaPlugin interface {
+	Plugin
+	AfterParse(schema *Schema, config *Config) error
+}
+
+// TemplatePlugin runs before each template renders. The hooks of every
+// file run one after the other before any template executes, changes to
+// data only apply to that file and changes to the schema apply to every
+// file.
+type TemplatePlugin interface {
+	Plugin
+	BeforeRender(templateName string, path string, data *TemplateData) error
+}
+
+// FuncsPlugin adds functions to every template, they replace the built-in
+// functions with the same name.
+type FuncsPlugin interface {
+	Plugin
+	Funcs(data *TemplateData) template.FuncMap
+}
+
+// OutputPlugin post-processes the rendered content before it is written.
+type OutputPlugin interface {
+	Plugin
+	AfterRender(path string, content []byte) ([]byte, error)
+}
+
+// WritePlugin runs after each file is written, files that are already up
+// to date on disk are not written again and have changed false.
+type WritePlugin interface {
+	Plugin
+	AfterWrite(path string, content []byte, changed bool) error
+}
@@ -1,6 +1,10 @@
 package types
 
-import "reflect"
+import (
+	"io/fs"
+	"reflect"
+	"strings"
+)
 
 type File uint
 type DBKind string
@@ -8,6 +12,7 @@ type Case float64
 type Server int
 type FilesAction bool
 type IgnoreHandler byte
+type ChangeAction string
 
 const (
 	FileDB = iota + 10
@@ -52,6 +57,12 @@ const (
 	DoNotGenerate FilesAction = false
 )
 
+const (
+	ChangeCreate ChangeAction = "create"
+	ChangeUpdate ChangeAction = "update"
+	ChangeDelete ChangeAction = "delete"
+)
+
 type Engine = func(tables []any, types ...any)
 type TypeMap map[string]reflect.Value
 type FieldMap map[string]reflect.StructField
@@ -61,6 +72,8 @@ type Paths struct {
 	BackendPath      string   `json:"backend_path,omitempty"`
 	TypescriptClient []string `json:"typescript_client,omitempty"`
 	ApiPath          string   `json:"api_path,omitempty"`
+	Templates        string   `json:"templates,omitempty"`
+	Manifest         string   `json:"manifest,omitempty"`
 }
 
 type Config struct {
@@ -75,6 +88,38 @@ type Config struct {
 	ApiPackage     string            `json:"api_package,omitempty"`
 	BackendPackage string            `json:"backend_package,omitempty"`
 	SkipRoutes     map[string]string `json:"skip_routes,omitempty"`
+	DryRun         bool              `json:"dry_run,omitempty"`
+	Diff           bool              `json:"diff,omitempty"`
+	Check          bool              `json:"check,omitempty"`
+	Clean          bool              `json:"clean,omitempty"`
+	Report         bool              `json:"report,omitempty"`
+	Doctor         bool              `json:"doctor,omitempty"`
+	Once           []File            `json:"once,omitempty"`
+	Templates      fs.FS             `json:"-"`
+	Plugins        []Plugin          `json:"-"`
+	Models         []Models          `json:"models,omitempty"`
+	Groups         []Group           `json:"groups,omitempty"`
+}
+
+// Group is one schema of a run with several, the embedded Config is merged
+// over the generator config so a group only sets what differs. Each group
+// is rendered as its own project with its own db package, typescript client
+// and manifest.
+type Group struct {
+	Name string `json:"name"`
+	Config
+	Tables []any `json:"-"`
+	Types  []any `json:"-"`
+}
+
+// Models lists the tables and extra types of a go package by name, they
+// are read from the package source when no tables are passed to the engine.
+// With no names every struct that embeds Model or is marked with
+// //gorming:table is a table.
+type Models struct {
+	Package string   `json:"package,omitempty"`
+	Tables  []string `json:"tables,omitempty"`
+	Types   []string `json:"types,omitempty"`
 }
 
 type Schema struct {
@@ -83,11 +128,13 @@ type Schema struct {
 }
 
 type Table struct {
-	Name         string   `json:"name,omitempty"`
-	Table        string   `json:"table,omitempty"`
-	HasTableFunc bool     `json:"has_table_func,omitempty"`
-	Columns      []Column `json:"columns,omitempty"`
-	Skip         []string `json:"skip,omitempty"`
+	Name         string         `json:"name,omitempty"`
+	Table        string         `json:"table,omitempty"`
+	Doc          string         `json:"doc,omitempty"`
+	HasTableFunc bool           `json:"has_table_func,omitempty"`
+	Columns      []Column       `json:"columns,omitempty"`
+	Skip         []string       `json:"skip,omitempty"`
+	Meta         map[string]any `json:"meta,omitempty"`
 }
 
 type ValidatorTag struct {
@@ -103,25 +150,110 @@ type Tags struct {
 	Validator  []ValidatorTag `json:"validator,omitempty"`
 	Swagger    SwaggerTag     `json:"swagger,omitempty"`
 	IgnoreEdge bool           `json:"ignore_edge,omitempty"`
+	Raw        string         `json:"raw,omitempty"`
 }
 
 type TemplateData struct {
 	Schema         *Schema `json:"schema,omitempty"`
 	ValidationList string  `json:"validation_list,omitempty"`
 	Config         Config  `json:"config,omitempty"`
+	Table          *Table  `json:"table,omitempty"`
 }
 
+// Target is a generated file: the template rendered with TemplateData and
+// the output path relative to Paths.BasePath. Path may use template actions,
+// with PerTable the target is rendered once per table with Table set.
+type Target struct {
+	Template string `json:"template,omitempty"`
+	Path     string `json:"path,omitempty"`
+	File     File   `json:"file,omitempty"`
+	PerTable bool   `json:"per_table,omitempty"`
+}
+
+// GormTag is the parsed gorm tag of a column, Settings has every key upper
+// cased the way gorm reads them, including the ones without a field.
 type GormTag struct {
-	PrimaryKey bool   `json:"primary_key,omitempty"`
-	Column     string `json:"column,omitempty"`
-	Default    string `json:"default,omitempty"`
+	PrimaryKey             bool              `json:"primary_key,omitempty"`
+	Column                 string            `json:"column,omitempty"`
+	Type                   string            `json:"type,omitempty"`
+	Serializer             string            `json:"serializer,omitempty"`
+	Size                   int               `json:"size,omitempty"`
+	Precision              int               `json:"precision,omitempty"`
+	Scale                  int               `json:"scale,omitempty"`
+	NotNull                bool              `json:"not_null,omitempty"`
+	Default                string            `json:"default,omitempty"`
+	Unique                 bool              `json:"unique,omitempty"`
+	AutoIncrement          bool              `json:"auto_increment,omitempty"`
+	AutoIncrementIncrement int               `json:"auto_increment_increment,omitempty"`
+	AutoCreateTime         bool              `json:"auto_create_time,omitempty"`
+	AutoCreateTimeUnit     string            `json:"auto_create_time_unit,omitempty"`
+	AutoUpdateTime         bool              `json:"auto_update_time,omitempty"`
+	AutoUpdateTimeUnit     string            `json:"auto_update_time_unit,omitempty"`
+	Indexes                []GormIndex       `json:"indexes,omitempty"`
+	Check                  string            `json:"check,omitempty"`
+	CheckName              string            `json:"check_name,omitempty"`
+	Comment                string            `json:"comment,omitempty"`
+	Embedded               bool              `json:"embedded,omitempty"`
+	EmbeddedPrefix         string            `json:"embedded_prefix,omitempty"`
+	ForeignKey             string            `json:"foreign_key,omitempty"`
+	References             string            `json:"reference,omitempty"`
+	JoinForeignKey         string            `json:"join_foreign_key,omitempty"`
+	JoinReferences         string            `json:"join_references,omitempty"`
+	Many2Many              string            `json:"many2many,omitempty"`
+	Polymorphic            string            `json:"polymorphic,omitempty"`
+	PolymorphicType        string            `json:"polymorphic_type,omitempty"`
+	PolymorphicID          string            `json:"polymorphic_id,omitempty"`
+	PolymorphicValue       string            `json:"polymorphic_value,omitempty"`
+	OnUpdate               string            `json:"on_update,omitempty"`
+	OnDelete               string            `json:"on_delete,omitempty"`
+	Ignore                 bool              `json:"ignore,omitempty"`
+	IgnoreMigration        bool              `json:"ignore_migration,omitempty"`
+	Read                   string            `json:"read,omitempty"`
+	Write                  string            `json:"write,omitempty"`
+	Settings               map[string]string `json:"settings,omitempty"`
+}
+
+// Readable reports whether gorm reads the column, see the -> permission.
+func (t GormTag) Readable() bool {
+	return !t.Ignore && t.Read != "false"
+}
+
+// Creatable reports whether gorm writes the column on create.
+func (t GormTag) Creatable() bool {
+	return t.writable("create")
+}
+
+// Updatable reports whether gorm writes the column on update.
+func (t GormTag) Updatable() bool {
+	return t.writable("update")
+}
+
+func (t GormTag) writable(action string) bool {
+	switch {
+	case t.Ignore:
+		return false
+	case t.Write != "":
+		return t.Write == "<-" || strings.Contains(t.Write, action)
+	}
+	return t.Read == ""
+}
+
+// GormIndex is one index or uniqueIndex of a column, Name is empty when
+// gorm names it.
+type GormIndex struct {
+	Name       string `json:"name,omitempty"`
 	Unique     bool   `json:"unique,omitempty"`
-	ForeignKey string `json:"foreign_key,omitempty"`
-	References string `json:"reference,omitempty"`
-	Ignore     bool   `json:"ignore,omitempty"`
-	OnUpdate   string `json:"on_update,omitempty"`
-	OnDelete   string `json:"on_delete,omitempty"`
-	Many2Many  string `json:"many2many,omitempty"`
+	Class      string `json:"class,omitempty"`
+	Type       string `json:"type,omitempty"`
+	Where      string `json:"where,omitempty"`
+	Comment    string `json:"comment,omitempty"`
+	Option     string `json:"option,omitempty"`
+	Expression string `json:"expression,omitempty"`
+	Sort       string `json:"sort,omitempty"`
+	Collate    string `json:"collate,omitempty"`
+	Composite  string `json:"composite,omitempty"`
+	Length     int    `json:"length,omitempty"`
+	Priority   int    `json:"priority,omitempty"`
 }
 
 type SwaggerTag struct {
@@ -156,13 +288,30 @@ type Edge struct {
 	LocalKey  string `json:"local_key,omitempty"`
 	TableKey  string `json:"table_key,omitempty"`
 	Many2Many string `json:"many2many,omitempty"`
+	// Polymorphic is the name of a polymorphic has one or has many edge,
+	// the rows of Table belong to this table when their TypeKey column is
+	// TypeValue, an empty TypeValue is the name of this table as in gorm.
+	Polymorphic string `json:"polymorphic,omitempty"`
+	TypeKey     string `json:"type_key,omitempty"`
+	TypeValue   string `json:"type_value,omitempty"`
+}
+
+type Change struct {
+	Path   string       `json:"path,omitempty"`
+	Action ChangeAction `json:"action,omitempty"`
+	Diff   string       `json:"diff,omitempty"`
 }
 
 type Column struct {
 	Name    string `json:"name,omitempty"`
 	Type    string `json:"type,omitempty"`
 	RawType string `json:"raw_type,omitempty"`
+	Doc     string `json:"doc,omitempty"`
 	Edge    *Edge  `json:"edge,omitempty"`
 	Slice   bool   `json:"slice,omitempty"`
 	Tags    Tags   `json:"tags,omitempty"`
+	// Fields are the columns of a struct with the gorm embedded tag, they
+	// are stored in the table with the EmbeddedPrefix.
+	Fields []Column       `json:"fields,omitempty"`
+	Meta   map[string]any `json:"meta,omitempty"`
 }
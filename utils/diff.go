@@ -0,0 +1,213 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxDiffEdits bounds the Myers search, past it the changed block is
+// reported as a whole removal followed by a whole insertion.
+const maxDiffEdits = 2000
+
+const diffContext = 3
+
+type diffLine struct {
+	kind byte
+	text string
+}
+
+// splitLines keeps the newline of every line, so a last line without one
+// differs from the same line with one.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func diffLines(a, b []string) []diffLine {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	out := []diffLine{}
+	for _, line := range a[:prefix] {
+		out = append(out, diffLine{' ', line})
+	}
+	out = append(out, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		out = append(out, diffLine{' ', line})
+	}
+	return out
+}
+
+func myers(a, b []string) []diffLine {
+	n, m := len(a), len(b)
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	trace := [][]int{}
+
+	for d := 0; d <= max; d++ {
+		if d > maxDiffEdits {
+			out := []diffLine{}
+			for _, line := range a {
+				out = append(out, diffLine{'-', line})
+			}
+			for _, line := range b {
+				out = append(out, diffLine{'+', line})
+			}
+			return out
+		}
+
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(a, b, trace)
+			}
+		}
+	}
+	return nil
+}
+
+func backtrack(a, b []string, trace [][]int) []diffLine {
+	reversed := []diffLine{}
+	x, y := len(a), len(b)
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		// trace[d] holds v[-d-1 .. d+1] as it was before step d
+		at := func(k int) int { return trace[d][k+d+1] }
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY && x > 0 && y > 0 {
+			reversed = append(reversed, diffLine{' ', a[x-1]})
+			x--
+			y--
+		}
+
+		if d > 0 {
+			if x == prevX {
+				reversed = append(reversed, diffLine{'+', b[y-1]})
+			} else {
+				reversed = append(reversed, diffLine{'-', a[x-1]})
+			}
+			x, y = prevX, prevY
+		}
+	}
+
+	out := make([]diffLine, 0, len(reversed))
+	for i := len(reversed) - 1; i >= 0; i-- {
+		out = append(out, reversed[i])
+	}
+	return out
+}
+
+// UnifiedDiff returns the unified diff between oldContent and newContent, empty when
+// both contents are equal.
+func UnifiedDiff(oldName, newName string, oldContent, newContent []byte) string {
+	if string(oldContent) == string(newContent) {
+		return ""
+	}
+
+	lines := diffLines(splitLines(string(oldContent)), splitLines(string(newContent)))
+	builder := new(strings.Builder)
+	fmt.Fprintf(builder, "--- %s\n+++ %s\n", oldName, newName)
+
+	for start := 0; start < len(lines); {
+		for start < len(lines) && lines[start].kind == ' ' {
+			start++
+		}
+		if start == len(lines) {
+			break
+		}
+
+		from := start - diffContext
+		if from < 0 {
+			from = 0
+		}
+
+		end, lastChange := start, start
+		for end < len(lines) {
+			if lines[end].kind != ' ' {
+				lastChange = end
+			} else if end-lastChange > 2*diffContext {
+				break
+			}
+			end++
+		}
+		to := lastChange + 1 + diffContext
+		if to > len(lines) {
+			to = len(lines)
+		}
+
+		oldStart, newStart := 1, 1
+		for _, line := range lines[:from] {
+			if line.kind != '+' {
+				oldStart++
+			}
+			if line.kind != '-' {
+				newStart++
+			}
+		}
+
+		oldCount, newCount := 0, 0
+		for _, line := range lines[from:to] {
+			if line.kind != '+' {
+				oldCount++
+			}
+			if line.kind != '-' {
+				newCount++
+			}
+		}
+
+		if oldCount == 0 {
+			oldStart--
+		}
+		if newCount == 0 {
+			newStart--
+		}
+
+		fmt.Fprintf(builder, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+		for _, line := range lines[from:to] {
+			builder.WriteByte(line.kind)
+			builder.WriteString(line.text)
+			if !strings.HasSuffix(line.text, "\n") {
+				builder.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+
+		start = to
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,153 @@
+package utils
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// applyPatch applies a unified diff to old the way patch(1) does, the
+// "\ No newline at end of file" marker removes the newline of the line
+// before it.
+func applyPatch(old string, diff string) (string, error) {
+	type patchLine struct {
+		kind byte
+		text string
+	}
+	type hunk struct {
+		oldStart, oldCount int
+		lines              []patchLine
+	}
+
+	hunks := []*hunk{}
+	for i, line := range strings.SplitAfter(diff, "\n") {
+		switch {
+		case line == "" || i < 2:
+		case strings.HasPrefix(line, "@@ "):
+			var oldStart, oldCount, newStart, newCount int
+			if _, err := fmt.Sscanf(line, "@@ -%d,%d +%d,%d @@", &oldStart, &oldCount, &newStart, &newCount); err != nil {
+				return "", fmt.Errorf("bad hunk header %q: %w", line, err)
+			}
+			hunks = append(hunks, &hunk{oldStart: oldStart, oldCount: oldCount})
+		case line == "\\ No newline at end of file\n":
+			if len(hunks) == 0 || len(hunks[len(hunks)-1].lines) == 0 {
+				return "", fmt.Errorf("marker without a line")
+			}
+			lines := hunks[len(hunks)-1].lines
+			lines[len(lines)-1].text = strings.TrimSuffix(lines[len(lines)-1].text, "\n")
+		case line[0] == ' ' || line[0] == '-' || line[0] == '+':
+			if len(hunks) == 0 {
+				return "", fmt.Errorf("line %q before any hunk", line)
+			}
+			h := hunks[len(hunks)-1]
+			h.lines = append(h.lines, patchLine{line[0], line[1:]})
+		default:
+			return "", fmt.Errorf("unexpected line %q", line)
+		}
+	}
+
+	oldLines := splitLines(old)
+	out := new(strings.Builder)
+	at := 0
+	for _, h := range hunks {
+		start := h.oldStart - 1
+		if h.oldCount == 0 {
+			start = h.oldStart
+		}
+		if start < at || start > len(oldLines) {
+			return "", fmt.Errorf("hunk at line %d is out of order", h.oldStart)
+		}
+		for ; at < start; at++ {
+			out.WriteString(oldLines[at])
+		}
+		for _, line := range h.lines {
+			switch line.kind {
+			case ' ', '-':
+				if at >= len(oldLines) || oldLines[at] != line.text {
+					return "", fmt.Errorf("hunk at line %d does not match line %d", h.oldStart, at+1)
+				}
+				if line.kind == ' ' {
+					out.WriteString(line.text)
+				}
+				at++
+			case '+':
+				out.WriteString(line.text)
+			}
+		}
+	}
+	for ; at < len(oldLines); at++ {
+		out.WriteString(oldLines[at])
+	}
+	return out.String(), nil
+}
+
+func TestUnifiedDiffApplies(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+	}{
+		{"adds the final newline", "a\nb", "a\nb\n"},
+		{"removes the final newline", "a\nb\n", "a\nb"},
+		{"changes a line without newline", "a\nb", "a\nc"},
+		{"appends after a line without newline", "a", "a\nb\n"},
+		{"creates a file without newline", "", "a\nb"},
+		{"deletes a file without newline", "a\nb", ""},
+		{"changes the middle", "a\nb\nc\nd\ne\nf\ng\nh\n", "a\nb\nc\nD\ne\nf\ng\nh\n"},
+		{"changes both ends", "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12", "0\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			diff := UnifiedDiff("a/f", "b/f", []byte(test.old), []byte(test.new))
+			if diff == "" {
+				t.Fatalf("no diff for different contents")
+			}
+			got, err := applyPatch(test.old, diff)
+			if err != nil {
+				t.Fatalf("apply: %v\n%s", err, diff)
+			}
+			if got != test.new {
+				t.Fatalf("got %q, want %q\n%s", got, test.new, diff)
+			}
+		})
+	}
+}
+
+func TestUnifiedDiffMarker(t *testing.T) {
+	want := "--- a/f\n+++ b/f\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n"
+	if got := UnifiedDiff("a/f", "b/f", []byte("a\nb"), []byte("a\nb\n")); got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestUnifiedDiffRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	content := func() string {
+		lines := []string{}
+		for i := r.Intn(12); i > 0; i-- {
+			lines = append(lines, strconv.Itoa(r.Intn(5)))
+		}
+		s := strings.Join(lines, "\n")
+		if s != "" && r.Intn(2) == 0 {
+			s += "\n"
+		}
+		return s
+	}
+
+	for i := 0; i < 300; i++ {
+		old, new := content(), content()
+		diff := UnifiedDiff("a/f", "b/f", []byte(old), []byte(new))
+		if (diff == "") != (old == new) {
+			t.Fatalf("diff of %q and %q is %q", old, new, diff)
+		}
+		got, err := applyPatch(old, diff)
+		if err != nil {
+			t.Fatalf("apply %q to %q: %v\n%s", new, old, err, diff)
+		}
+		if got != new {
+			t.Fatalf("got %q, want %q\n%s", got, new, diff)
+		}
+	}
+}
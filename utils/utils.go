@@ -1,7 +1,7 @@
 package utils
 
 import (
-	"log"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -23,6 +23,62 @@ func Snake(s string) string {
 	return strcase.ToSnake(s)
 }
 
+// initialisms are the words gorm keeps together when it converts a field
+// name to a column name.
+var initialisms = []string{
+	"API", "ASCII", "CPU", "CSS", "DNS", "EOF", "GUID", "HTML", "HTTP", "HTTPS", "ID", "IP", "JSON", "LHS", "QPS", "RAM", "RHS",
+	"RPC", "SLA", "SMTP", "SSH", "TLS", "TTL", "UID", "UI", "UUID", "URI", "URL", "UTF8", "VM", "XML", "XSRF", "XSS",
+}
+
+var initialismsReplacer = func() *strings.Replacer {
+	pairs := []string{}
+	for _, initialism := range initialisms {
+		pairs = append(pairs, initialism, initialism[:1]+strings.ToLower(initialism[1:]))
+	}
+	return strings.NewReplacer(pairs...)
+}()
+
+// DBName converts a go name to a column name the way the default gorm
+// NamingStrategy does, UserID is user_id and HTTPServer is http_server.
+func DBName(name string) string {
+	if name == "" {
+		return ""
+	}
+
+	value := initialismsReplacer.Replace(name)
+	upper := func(b byte) bool { return b >= 'A' && b <= 'Z' }
+	buffer := strings.Builder{}
+	lastCase, curCase := false, upper(value[0])
+
+	for i := 0; i < len(value)-1; i++ {
+		v := value[i]
+		nextCase := upper(value[i+1])
+		nextNumber := value[i+1] >= '0' && value[i+1] <= '9'
+
+		if curCase {
+			if !(lastCase && (nextCase || nextNumber)) && i > 0 && value[i-1] != '_' && value[i+1] != '_' {
+				buffer.WriteByte('_')
+			}
+			buffer.WriteByte(v + 32)
+		} else {
+			buffer.WriteByte(v)
+		}
+
+		lastCase, curCase = curCase, nextCase
+	}
+
+	last := value[len(value)-1]
+	if curCase {
+		if !lastCase && len(value) > 1 {
+			buffer.WriteByte('_')
+		}
+		buffer.WriteByte(last + 32)
+	} else {
+		buffer.WriteByte(last)
+	}
+	return buffer.String()
+}
+
 func Snakes(s string) string {
 	return strcase.ToSnake(inflection.Plural(s))
 }
@@ -72,22 +128,23 @@ func CleanString(s string, parts ...string) string {
 	return s
 }
 
-func CurrentGoMod() (string, string) {
+func CurrentGoMod() (string, string, error) {
 	cwd, err := os.Getwd()
 	if err != nil {
-		log.Fatalln(err)
+		return "", "", err
 	}
 
 	for {
 		if data, err := os.ReadFile(filepath.Join(cwd, "go.mod")); err == nil {
-			return cwd, CleanString(strings.Split(strings.Split(string(data), "\n")[0], " ")[1])
+			return cwd, CleanString(strings.Split(strings.Split(string(data), "\n")[0], " ")[1]), nil
 		}
 
-		if cwd == "." {
-			log.Fatalln("gorming: cannot find go.mod")
+		parent := filepath.Dir(cwd)
+		if parent == cwd {
+			return "", "", errors.New("cannot find go.mod")
 		}
 
-		cwd = filepath.Dir(cwd)
+		cwd = parent
 	}
 
 }
@@ -95,3 +152,36 @@ func CurrentGoMod() (string, string) {
 func UID() string {
 	return strings.ToUpper(xid.New().String())
 }
+
+// Closest returns the candidate nearest to word when it is at most two
+// edits away, case is ignored.
+func Closest(word string, candidates ...string) string {
+	best, bestDistance := "", 3
+	for _, candidate := range candidates {
+		if d := distance(strings.ToLower(word), strings.ToLower(candidate)); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best
+}
+
+// distance is the levenshtein distance of a and b.
+func distance(a string, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,48 @@
+package utils
+
+import "testing"
+
+// TestDBName checks DBName against the outputs of gorm's toDBName, the
+// first block is the table of gorm's own naming tests.
+func TestDBName(t *testing.T) {
+	tests := []struct{ name, want string }{
+		{"", ""},
+		{"x", "x"},
+		{"X", "x"},
+		{"userRestrictions", "user_restrictions"},
+		{"ThisIsATest", "this_is_a_test"},
+		{"PFAndESI", "pf_and_esi"},
+		{"AbcAndJkl", "abc_and_jkl"},
+		{"EmployeeID", "employee_id"},
+		{"SKU_ID", "sku_id"},
+		{"FieldX", "field_x"},
+		{"HTTPAndSMTP", "http_and_smtp"},
+		{"HTTPServerHandlerForURLID", "http_server_handler_for_url_id"},
+		{"UUID", "uuid"},
+		{"HTTPURL", "http_url"},
+		{"HTTP_URL", "http_url"},
+		{"SHA256Hash", "sha256_hash"},
+		{"SHA256HASH", "sha256_hash"},
+		{"ThisIsActuallyATestSoWeMayBeAbleToUseThisCodeInGormPackageAlsoIdCanBeUsedAtTheEndAsID", "this_is_actually_a_test_so_we_may_be_able_to_use_this_code_in_gorm_package_also_id_can_be_used_at_the_end_as_id"},
+
+		{"UserID", "user_id"},
+		{"HTTPServer", "http_server"},
+		{"ID", "id"},
+		{"UUIDv4", "uuidv4"},
+		{"A1B2", "a1_b2"},
+		{"already_snake", "already_snake"},
+		{"OwnerType", "owner_type"},
+		{"CreatedAt", "created_at"},
+		{"APIKey", "api_key"},
+		{"URLs", "urls"},
+		{"IDs", "ids"},
+		{"Oauth2Token", "oauth2_token"},
+		{"HTMLToPDF", "html_to_pdf"},
+	}
+
+	for _, test := range tests {
+		if got := DBName(test.name); got != test.want {
+			t.Errorf("DBName(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}